@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/jmaeagle99/chirpy/internal/database"
+)
+
+type DeleteAccountRequest struct {
+	CurrentPassword *string `json:"current_password"`
+}
+
+type AccountDeletionResponse struct {
+	DeleteAfter time.Time `json:"delete_after"`
+}
+
+const defaultDeletionGracePeriod = 30 * 24 * time.Hour
+
+// loadDeletionGracePeriod reads how long deleted accounts can still be
+// restored from ACCOUNT_DELETION_GRACE_PERIOD, such as "720h".
+func loadDeletionGracePeriod() (time.Duration, error) {
+	value := os.Getenv("ACCOUNT_DELETION_GRACE_PERIOD")
+	if len(value) == 0 {
+		return defaultDeletionGracePeriod, nil
+	}
+
+	return time.ParseDuration(value)
+}
+
+// deleteMyAccount schedules the caller's account for deletion and logs them
+// out everywhere. Logging in again before the grace period ends restores the
+// account; after that, purgeDeletedAccounts removes it for good.
+func (cfg *apiConfig) deleteMyAccount(w http.ResponseWriter, r *http.Request) {
+	userId, err := cfg.validateUserAccess(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	request := DeleteAccountRequest{}
+
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&request)
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	if !cfg.checkCurrentPassword(w, r, userId, request.CurrentPassword) {
+		return
+	}
+
+	var user database.User
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		user, err = q.ScheduleUserDeletion(r.Context(), database.ScheduleUserDeletionParams{
+			ID:          userId,
+			DeleteAfter: sql.NullTime{Time: time.Now().UTC().Add(cfg.deletionGracePeriod), Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+
+		err = q.RevokeAllUserRefreshTokens(r.Context(), userId)
+		if err != nil {
+			return err
+		}
+
+		return invalidateUserTokens(r.Context(), q, userId)
+	})
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	writeAsJson(
+		w,
+		AccountDeletionResponse{
+			DeleteAfter: user.DeleteAfter.Time,
+		},
+		http.StatusAccepted)
+}
+
+// purgeDeletedAccounts removes accounts whose grace period has ended. Their
+// chirps, tokens and other rows go with them through ON DELETE CASCADE.
+func (cfg *apiConfig) purgeDeletedAccounts(ctx context.Context) error {
+	return cfg.withTx(ctx, func(q *database.Queries) error {
+		userIds, err := q.PurgeDeletedUsers(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, userId := range userIds {
+			_, err = q.ClearLoginThrottle(ctx, database.ClearLoginThrottleParams{
+				Scope:   database.LoginThrottleScopeAccount,
+				Subject: accountSubject(userId),
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
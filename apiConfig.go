@@ -1,19 +1,118 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
+	"io"
 	"net/http"
+	"os"
 	"sync/atomic"
+	"time"
 
+	"github.com/jmaeagle99/chirpy/internal/auth"
 	"github.com/jmaeagle99/chirpy/internal/database"
+	"github.com/jmaeagle99/chirpy/internal/mail"
 )
 
 type apiConfig struct {
-	db             *database.Queries
-	fileserverHits atomic.Int32
-	platform       string
-	polkaKey       string
-	tokenSecret    string
+	bannedWords *bannedWordFilter
+	conn        *sql.DB
+	db          *database.Queries
+	// deletionGracePeriod is how long a deleted account can be restored by
+	// logging in before it is purged.
+	deletionGracePeriod time.Duration
+	fileserverHits      atomic.Int32
+	loginPolicies       loginPolicies
+	mailer              mail.Mailer
+	platform            string
+	polkaKey            string
+	tokenKeys           *auth.KeyRing
+	webhookSecrets      []string
+	// verifiedEmailRequired blocks chirping until the user's email address
+	// is verified.
+	verifiedEmailRequired bool
+}
+
+// loadTokenKeys signs access tokens with the PEM keys in JWT_KEYS_DIR when it
+// is set, still accepting tokens signed with TOKEN_SECRET if that is also set.
+// Otherwise tokens are signed with TOKEN_SECRET alone.
+func loadTokenKeys() (*auth.KeyRing, error) {
+	keysDir := os.Getenv("JWT_KEYS_DIR")
+	tokenSecret := os.Getenv("TOKEN_SECRET")
+
+	if len(keysDir) == 0 {
+		return auth.NewSecretKeyRing(tokenSecret)
+	}
+
+	ring, err := auth.LoadKeyRing(keysDir, os.Getenv("JWT_SIGNING_KEY_ID"))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tokenSecret) > 0 {
+		err = ring.AcceptLegacySecret(tokenSecret)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ring, nil
+}
+
+// loadMailer delivers mail through the SMTP server at SMTP_ADDR when it is
+// set. Otherwise messages are appended to MAIL_LOG_FILE, or written to
+// standard output, so password reset links can be followed in development.
+func loadMailer() (mail.Mailer, error) {
+	from := os.Getenv("MAIL_FROM")
+	if len(from) == 0 {
+		from = "chirpy@localhost"
+	}
+
+	smtpAddr := os.Getenv("SMTP_ADDR")
+	if len(smtpAddr) > 0 {
+		return &mail.SMTPMailer{
+			Addr:     smtpAddr,
+			From:     from,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+		}, nil
+	}
+
+	var writer io.Writer = os.Stdout
+	logFile := os.Getenv("MAIL_LOG_FILE")
+	if len(logFile) > 0 {
+		file, err := os.OpenFile(logFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return nil, err
+		}
+		writer = file
+	}
+
+	return mail.NewLogMailer(from, writer), nil
+}
+
+func (cfg *apiConfig) getJWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	writeAsJson(
+		w,
+		cfg.tokenKeys.JWKS(),
+		http.StatusOK)
+}
+
+func (cfg *apiConfig) withTx(ctx context.Context, fn func(*database.Queries) error) error {
+	tx, err := cfg.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = fn(cfg.db.WithTx(tx))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 func (cfg *apiConfig) middlewareMetricsInc(next http.Handler) http.Handler {
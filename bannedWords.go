@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmaeagle99/chirpy/internal/database"
+	"github.com/jmaeagle99/chirpy/internal/profanity"
+)
+
+const (
+	bannedWordsRefreshInterval = 5 * time.Second
+	bannedWordUniqueConstraint = "banned_words_word_match_mode_key"
+)
+
+type BannedWordRequest struct {
+	Word      string `json:"word"`
+	MatchMode string `json:"match_mode"`
+}
+
+type BannedWordResponse struct {
+	Id        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Word      string    `json:"word"`
+	MatchMode string    `json:"match_mode"`
+}
+
+// bannedWordFilter caches the compiled banned word list and reloads it from
+// the database once it is older than the refresh interval, so changes made
+// through any instance take effect everywhere within a few seconds.
+type bannedWordFilter struct {
+	db       *database.Queries
+	interval time.Duration
+
+	mu       sync.Mutex
+	filter   *profanity.Filter
+	loadedAt time.Time
+}
+
+func newBannedWordFilter(db *database.Queries, interval time.Duration) *bannedWordFilter {
+	return &bannedWordFilter{
+		db:       db,
+		interval: interval,
+	}
+}
+
+func (cache *bannedWordFilter) current(ctx context.Context) (*profanity.Filter, error) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if cache.filter != nil && time.Since(cache.loadedAt) < cache.interval {
+		return cache.filter, nil
+	}
+
+	filter, err := cache.load(ctx)
+	if err != nil {
+		if cache.filter != nil {
+			log.Printf("keeping previous banned word list: %v", err)
+			return cache.filter, nil
+		}
+		return nil, err
+	}
+
+	cache.filter = filter
+	cache.loadedAt = time.Now()
+	return filter, nil
+}
+
+func (cache *bannedWordFilter) load(ctx context.Context) (*profanity.Filter, error) {
+	words, err := cache.db.GetBannedWords(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return profanity.NewFilter(Map(words, func(word database.BannedWord) profanity.Rule {
+		return profanity.Rule{
+			Word: word.Word,
+			Mode: profanity.MatchMode(word.MatchMode),
+		}
+	}))
+}
+
+// invalidate forces the next chirp on this instance to reload the list.
+func (cache *bannedWordFilter) invalidate() {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	cache.loadedAt = time.Time{}
+}
+
+func (cache *bannedWordFilter) Censor(ctx context.Context, text string) (string, error) {
+	filter, err := cache.current(ctx)
+	if err != nil {
+		return "", err
+	}
+	return filter.Censor(text), nil
+}
+
+func (cfg *apiConfig) getBannedWords(w http.ResponseWriter, r *http.Request) {
+	words, err := cfg.db.GetBannedWords(r.Context())
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	writeAsJson(
+		w,
+		Map(words, convertBannedWord),
+		http.StatusOK)
+}
+
+func (cfg *apiConfig) createBannedWord(w http.ResponseWriter, r *http.Request) {
+	request := BannedWordRequest{}
+
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&request)
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	if len(request.MatchMode) == 0 {
+		request.MatchMode = string(profanity.WholeWord)
+	}
+
+	_, err = profanity.CompileRule(profanity.Rule{
+		Word: request.Word,
+		Mode: profanity.MatchMode(request.MatchMode),
+	})
+	if err != nil {
+		writeAsJson(
+			w,
+			ErrorResponse{
+				Error: err.Error(),
+			},
+			http.StatusBadRequest)
+		return
+	}
+
+	word, err := cfg.db.CreateBannedWord(r.Context(), database.CreateBannedWordParams{
+		Word:      request.Word,
+		MatchMode: database.BannedWordMatchMode(request.MatchMode),
+	})
+	if isUniqueViolation(err, bannedWordUniqueConstraint) {
+		writeAsJson(
+			w,
+			ErrorResponse{
+				Error: "word is already banned",
+			},
+			http.StatusConflict)
+		return
+	}
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	cfg.bannedWords.invalidate()
+
+	writeAsJson(
+		w,
+		convertBannedWord(word),
+		http.StatusCreated)
+}
+
+func (cfg *apiConfig) deleteBannedWord(w http.ResponseWriter, r *http.Request) {
+	wordId, err := uuid.Parse(r.PathValue("wordID"))
+	if err != nil {
+		writeAsJson(
+			w,
+			ErrorResponse{
+				Error: "wordId is not valid",
+			},
+			http.StatusBadRequest)
+		return
+	}
+
+	deleted, err := cfg.db.DeleteBannedWord(r.Context(), wordId)
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	if deleted == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	cfg.bannedWords.invalidate()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func convertBannedWord(word database.BannedWord) BannedWordResponse {
+	return BannedWordResponse{
+		Id:        word.ID,
+		CreatedAt: word.CreatedAt,
+		Word:      word.Word,
+		MatchMode: string(word.MatchMode),
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/jmaeagle99/chirpy/internal/database"
+)
+
+func (cfg *apiConfig) likeChirp(w http.ResponseWriter, r *http.Request) {
+	userId, err := cfg.validateUserAccess(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	chirpId, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		writeAsJson(
+			w,
+			ErrorResponse{
+				Error: "chirpId is not valid",
+			},
+			http.StatusBadRequest)
+		return
+	}
+
+	_, err = cfg.db.GetChirp(r.Context(), chirpId)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	err = cfg.db.LikeChirp(r.Context(), database.LikeChirpParams{
+		ChirpID: chirpId,
+		UserID:  userId,
+	})
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) unlikeChirp(w http.ResponseWriter, r *http.Request) {
+	userId, err := cfg.validateUserAccess(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	chirpId, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		writeAsJson(
+			w,
+			ErrorResponse{
+				Error: "chirpId is not valid",
+			},
+			http.StatusBadRequest)
+		return
+	}
+
+	err = cfg.db.UnlikeChirp(r.Context(), database.UnlikeChirpParams{
+		ChirpID: chirpId,
+		UserID:  userId,
+	})
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) getUserLikes(w http.ResponseWriter, r *http.Request) {
+	userId, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		writeAsJson(
+			w,
+			ErrorResponse{
+				Error: "userId is not valid",
+			},
+			http.StatusBadRequest)
+		return
+	}
+
+	page, err := parsePageRequest(r, sortDesc)
+	if err != nil {
+		writeAsJson(
+			w,
+			ErrorResponse{
+				Error: err.Error(),
+			},
+			http.StatusBadRequest)
+		return
+	}
+
+	likes, err := cfg.db.ListLikedChirps(r.Context(), database.ListLikedChirpsParams{
+		UserID:        userId,
+		BeforeLikedAt: page.cursorCreatedAt(),
+		BeforeID:      page.cursorId(),
+		Limit:         page.queryLimit(),
+	})
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	likes, next_cursor := trimPage(page, likes, func(like database.ListLikedChirpsRow) pageCursor {
+		return pageCursor{
+			CreatedAt: like.LikedAt,
+			Id:        like.Chirp.ID,
+		}
+	})
+
+	chirpsResponse, err := cfg.convertChirps(
+		r.Context(),
+		cfg.viewerAccess(r),
+		Map(likes, func(like database.ListLikedChirpsRow) database.Chirp {
+			return like.Chirp
+		}))
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	writeAsJson(
+		w,
+		ChirpsPageResponse{
+			Chirps:     chirpsResponse,
+			NextCursor: next_cursor,
+		},
+		http.StatusOK)
+}
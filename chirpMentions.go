@@ -0,0 +1,54 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/jmaeagle99/chirpy/internal/database"
+)
+
+func (cfg *apiConfig) getMyMentions(w http.ResponseWriter, r *http.Request) {
+	userId, err := cfg.validateUserAccess(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	page, err := parsePageRequest(r, sortDesc)
+	if err != nil {
+		writeAsJson(
+			w,
+			ErrorResponse{
+				Error: err.Error(),
+			},
+			http.StatusBadRequest)
+		return
+	}
+
+	chirps, err := cfg.db.ListMentioningChirps(r.Context(), database.ListMentioningChirpsParams{
+		UserID:          userId,
+		BeforeCreatedAt: page.cursorCreatedAt(),
+		BeforeID:        page.cursorId(),
+		Limit:           page.queryLimit(),
+	})
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	chirps, next_cursor := trimPage(page, chirps, chirpCursorKey)
+
+	chirpsResponse, err := cfg.convertChirps(r.Context(), uuid.NullUUID{UUID: userId, Valid: true}, chirps)
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	writeAsJson(
+		w,
+		ChirpsPageResponse{
+			Chirps:     chirpsResponse,
+			NextCursor: next_cursor,
+		},
+		http.StatusOK)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmaeagle99/chirpy/internal/database"
+)
+
+type ChirpRevisionResponse struct {
+	Id        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	ChirpId   uuid.UUID `json:"chirp_id"`
+	Body      string    `json:"body"`
+}
+
+func (cfg *apiConfig) getChirpRevisions(w http.ResponseWriter, r *http.Request) {
+	chirpId, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		writeAsJson(
+			w,
+			ErrorResponse{
+				Error: "chirpId is not valid",
+			},
+			http.StatusBadRequest)
+		return
+	}
+
+	_, err = cfg.db.GetChirp(r.Context(), chirpId)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	revisions, err := cfg.db.GetChirpRevisions(r.Context(), chirpId)
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	writeAsJson(
+		w,
+		Map(revisions, convertChirpRevision),
+		http.StatusOK)
+}
+
+func convertChirpRevision(revision database.ChirpRevision) ChirpRevisionResponse {
+	return ChirpRevisionResponse{
+		Id:        revision.ID,
+		CreatedAt: revision.CreatedAt,
+		ChirpId:   revision.ChirpID,
+		Body:      revision.Body,
+	}
+}
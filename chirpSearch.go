@@ -0,0 +1,93 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/jmaeagle99/chirpy/internal/database"
+	"github.com/jmaeagle99/chirpy/internal/search"
+)
+
+func (cfg *apiConfig) searchChirps(w http.ResponseWriter, r *http.Request) {
+	query, err := search.ToTSQuery(r.URL.Query().Get("q"))
+	if err != nil {
+		writeAsJson(
+			w,
+			ErrorResponse{
+				Error: err.Error(),
+			},
+			http.StatusBadRequest)
+		return
+	}
+
+	page, err := parsePageRequest(r, sortDesc)
+	if err == nil && page.Cursor != nil && !page.Cursor.Rank.Valid {
+		err = errInvalidCursor
+	}
+	if err != nil {
+		writeAsJson(
+			w,
+			ErrorResponse{
+				Error: err.Error(),
+			},
+			http.StatusBadRequest)
+		return
+	}
+
+	author_id := uuid.NullUUID{}
+	author_id_qparam := r.URL.Query().Get("author_id")
+	if len(author_id_qparam) > 0 {
+		user_id, err := uuid.Parse(author_id_qparam)
+		if err != nil {
+			writeAsJson(
+				w,
+				ErrorResponse{
+					Error: "author_id is not valid",
+				},
+				http.StatusBadRequest)
+			return
+		}
+		author_id = uuid.NullUUID{UUID: user_id, Valid: true}
+	}
+
+	results, err := cfg.db.SearchChirps(r.Context(), database.SearchChirpsParams{
+		Query:           query,
+		AuthorID:        author_id,
+		BeforeRank:      page.cursorRank(),
+		BeforeCreatedAt: page.cursorCreatedAt(),
+		BeforeID:        page.cursorId(),
+		Limit:           page.queryLimit(),
+	})
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	results, next_cursor := trimPage(page, results, func(result database.SearchChirpsRow) pageCursor {
+		return pageCursor{
+			Rank:      sql.NullFloat64{Float64: float64(result.Rank), Valid: true},
+			CreatedAt: result.Chirp.CreatedAt,
+			Id:        result.Chirp.ID,
+		}
+	})
+
+	chirpsResponse, err := cfg.convertChirps(
+		r.Context(),
+		cfg.viewerAccess(r),
+		Map(results, func(result database.SearchChirpsRow) database.Chirp {
+			return result.Chirp
+		}))
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	writeAsJson(
+		w,
+		ChirpsPageResponse{
+			Chirps:     chirpsResponse,
+			NextCursor: next_cursor,
+		},
+		http.StatusOK)
+}
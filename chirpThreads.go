@@ -0,0 +1,96 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/jmaeagle99/chirpy/internal/database"
+)
+
+type ChirpThreadResponse struct {
+	Ancestors       []ChirpResponse `json:"ancestors"`
+	AncestorDeleted bool            `json:"ancestor_deleted"`
+	Chirp           ChirpResponse   `json:"chirp"`
+	Replies         []ChirpResponse `json:"replies"`
+	NextCursor      string          `json:"next_cursor,omitempty"`
+}
+
+// getChirpThread returns the chirp with its ancestor chain ordered from the
+// root down and a page of its direct replies. Replies keep the id of a deleted
+// chirp, so a chain stops at the first deleted ancestor and ancestor_deleted
+// tells that the thread went on above it.
+func (cfg *apiConfig) getChirpThread(w http.ResponseWriter, r *http.Request) {
+	chirpId, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		writeAsJson(
+			w,
+			ErrorResponse{
+				Error: "chirpId is not valid",
+			},
+			http.StatusBadRequest)
+		return
+	}
+
+	page, err := parsePageRequest(r, sortAsc)
+	if err != nil {
+		writeAsJson(
+			w,
+			ErrorResponse{
+				Error: err.Error(),
+			},
+			http.StatusBadRequest)
+		return
+	}
+
+	chirp, err := cfg.db.GetChirp(r.Context(), chirpId)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	ancestors, err := cfg.db.GetChirpAncestors(r.Context(), chirp.ID)
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	replies, err := cfg.db.ListChirpReplies(r.Context(), database.ListChirpRepliesParams{
+		ParentID:       uuid.NullUUID{UUID: chirp.ID, Valid: true},
+		AfterCreatedAt: page.cursorCreatedAt(),
+		AfterID:        page.cursorId(),
+		Limit:          page.queryLimit(),
+	})
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	replies, next_cursor := trimPage(page, replies, chirpCursorKey)
+
+	thread := make([]database.Chirp, 0, len(ancestors)+1+len(replies))
+	thread = append(thread, ancestors...)
+	thread = append(thread, chirp)
+	thread = append(thread, replies...)
+
+	top := chirp
+	if len(ancestors) > 0 {
+		top = ancestors[0]
+	}
+
+	threadResponse, err := cfg.convertChirps(r.Context(), cfg.viewerAccess(r), thread)
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	writeAsJson(
+		w,
+		ChirpThreadResponse{
+			Ancestors:       threadResponse[:len(ancestors)],
+			AncestorDeleted: top.InReplyTo.Valid,
+			Chirp:           threadResponse[len(ancestors)],
+			Replies:         threadResponse[len(ancestors)+1:],
+			NextCursor:      next_cursor,
+		},
+		http.StatusOK)
+}
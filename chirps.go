@@ -1,18 +1,21 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
-	"regexp"
-	"sort"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jmaeagle99/chirpy/internal/chirptext"
 	"github.com/jmaeagle99/chirpy/internal/database"
 )
 
 type ChirpRequest struct {
-	Body string `json:"body"`
+	Body      string     `json:"body"`
+	InReplyTo *uuid.UUID `json:"in_reply_to"`
 }
 
 type ErrorResponse struct {
@@ -20,17 +23,27 @@ type ErrorResponse struct {
 }
 
 type ChirpResponse struct {
-	Id        uuid.UUID `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Body      string    `json:"body"`
-	UserId    uuid.UUID `json:"user_id"`
+	Id         uuid.UUID         `json:"id"`
+	CreatedAt  time.Time         `json:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+	Body       string            `json:"body"`
+	UserId     uuid.UUID         `json:"user_id"`
+	InReplyTo  *uuid.UUID        `json:"in_reply_to"`
+	ReplyCount int64             `json:"reply_count"`
+	LikeCount  int64             `json:"like_count"`
+	LikedByMe  *bool             `json:"liked_by_me,omitempty"`
+	Hashtags   []string          `json:"hashtags"`
+	Mentions   []MentionResponse `json:"mentions"`
 }
 
-var bannedWords = []string{
-	"kerfuffle",
-	"sharbert",
-	"fornax",
+type MentionResponse struct {
+	UserId   uuid.UUID `json:"user_id"`
+	Username string    `json:"username"`
+}
+
+type ChirpsPageResponse struct {
+	Chirps     []ChirpResponse `json:"chirps"`
+	NextCursor string          `json:"next_cursor,omitempty"`
 }
 
 func Map[T any, U any](input []T, fn func(T) U) []U {
@@ -41,9 +54,21 @@ func Map[T any, U any](input []T, fn func(T) U) []U {
 	return result
 }
 
-var bannedWordRegexps = Map(bannedWords, func(word string) *regexp.Regexp {
-	return regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(word) + `\b`)
-})
+const maxChirpLength = 140
+
+var (
+	errChirpNotFound  = errors.New("chirp not found")
+	errChirpForbidden = errors.New("chirp belongs to another user")
+	errChirpTooLong   = errors.New("Chirp is too long")
+)
+
+func (cfg *apiConfig) cleanChirpBody(ctx context.Context, body string) (string, error) {
+	if len(body) > maxChirpLength {
+		return "", errChirpTooLong
+	}
+
+	return cfg.bannedWords.Censor(ctx, body)
+}
 
 func (cfg *apiConfig) createChirp(w http.ResponseWriter, r *http.Request) {
 	userId, err := cfg.validateUserAccess(r)
@@ -52,6 +77,10 @@ func (cfg *apiConfig) createChirp(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !cfg.requireVerifiedEmail(w, r, userId) {
+		return
+	}
+
 	request := ChirpRequest{}
 
 	decoder := json.NewDecoder(r.Body)
@@ -61,36 +90,183 @@ func (cfg *apiConfig) createChirp(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if len(request.Body) > 140 {
+	content, err := cfg.cleanChirpBody(r.Context(), request.Body)
+	if errors.Is(err, errChirpTooLong) {
 		writeAsJson(
 			w,
 			ErrorResponse{
-				Error: "Chirp is too long",
+				Error: err.Error(),
 			},
 			http.StatusBadRequest)
 		return
 	}
+	if err != nil {
+		writeServerError(w)
+		return
+	}
 
-	content := request.Body
-	for _, regexp := range bannedWordRegexps {
-		content = regexp.ReplaceAllString(content, "****")
+	in_reply_to := uuid.NullUUID{}
+	if request.InReplyTo != nil {
+		parent, err := cfg.db.GetChirp(r.Context(), *request.InReplyTo)
+		if err != nil {
+			writeAsJson(
+				w,
+				ErrorResponse{
+					Error: "in_reply_to is not valid",
+				},
+				http.StatusBadRequest)
+			return
+		}
+		in_reply_to = uuid.NullUUID{UUID: parent.ID, Valid: true}
 	}
 
-	chirp, err := cfg.db.CreateChirp(r.Context(), database.CreateChirpParams{
-		Body:   content,
-		UserID: userId,
+	var chirp database.Chirp
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		chirp, err = q.CreateChirp(r.Context(), database.CreateChirpParams{
+			Body:      content,
+			UserID:    userId,
+			InReplyTo: in_reply_to,
+		})
+		if err != nil {
+			return err
+		}
+
+		return indexChirpBody(r.Context(), q, chirp)
 	})
 	if err != nil {
 		writeServerError(w)
 		return
 	}
 
+	chirpResponse, err := cfg.convertChirp(r.Context(), uuid.NullUUID{UUID: userId, Valid: true}, chirp)
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
 	writeAsJson(
 		w,
-		convertChirp(chirp),
+		chirpResponse,
 		http.StatusCreated)
 }
 
+func (cfg *apiConfig) updateChirp(w http.ResponseWriter, r *http.Request) {
+	userId, err := cfg.validateUserAccess(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	chirpId, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		writeAsJson(
+			w,
+			ErrorResponse{
+				Error: "chirpId is not valid",
+			},
+			http.StatusBadRequest)
+		return
+	}
+
+	if !cfg.requireVerifiedEmail(w, r, userId) {
+		return
+	}
+
+	request := ChirpRequest{}
+
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&request)
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	content, err := cfg.cleanChirpBody(r.Context(), request.Body)
+	if errors.Is(err, errChirpTooLong) {
+		writeAsJson(
+			w,
+			ErrorResponse{
+				Error: err.Error(),
+			},
+			http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	var chirp database.Chirp
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		current, err := q.GetChirpForUpdate(r.Context(), chirpId)
+		if errors.Is(err, sql.ErrNoRows) {
+			return errChirpNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		if current.UserID != userId {
+			return errChirpForbidden
+		}
+
+		if current.Body == content {
+			chirp = current
+			return nil
+		}
+
+		_, err = q.CreateChirpRevision(r.Context(), database.CreateChirpRevisionParams{
+			ChirpID: current.ID,
+			Body:    current.Body,
+		})
+		if err != nil {
+			return err
+		}
+
+		chirp, err = q.UpdateChirpBody(r.Context(), database.UpdateChirpBodyParams{
+			ID:   current.ID,
+			Body: content,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = q.UntagChirp(r.Context(), chirp.ID)
+		if err != nil {
+			return err
+		}
+
+		err = q.ClearChirpMentions(r.Context(), chirp.ID)
+		if err != nil {
+			return err
+		}
+
+		return indexChirpBody(r.Context(), q, chirp)
+	})
+	switch {
+	case errors.Is(err, errChirpNotFound):
+		w.WriteHeader(http.StatusNotFound)
+		return
+	case errors.Is(err, errChirpForbidden):
+		w.WriteHeader(http.StatusForbidden)
+		return
+	case err != nil:
+		writeServerError(w)
+		return
+	}
+
+	chirpResponse, err := cfg.convertChirp(r.Context(), uuid.NullUUID{UUID: userId, Valid: true}, chirp)
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	writeAsJson(
+		w,
+		chirpResponse,
+		http.StatusOK)
+}
+
 func (cfg *apiConfig) deleteChirp(w http.ResponseWriter, r *http.Request) {
 	userId, err := cfg.validateUserAccess(r)
 	if err != nil {
@@ -130,53 +306,73 @@ func (cfg *apiConfig) deleteChirp(w http.ResponseWriter, r *http.Request) {
 }
 
 func (cfg *apiConfig) getAllChirps(w http.ResponseWriter, r *http.Request) {
-	author_id_qparam := r.URL.Query().Get("author_id")
+	order := sortAsc
+	if r.URL.Query().Get("sort") == "desc" {
+		order = sortDesc
+	}
 
-	var chirps []database.Chirp
+	page, err := parsePageRequest(r, order)
+	if err != nil {
+		writeAsJson(
+			w,
+			ErrorResponse{
+				Error: err.Error(),
+			},
+			http.StatusBadRequest)
+		return
+	}
+
+	author_id := uuid.NullUUID{}
+	author_id_qparam := r.URL.Query().Get("author_id")
 	if len(author_id_qparam) > 0 {
 		user_id, err := uuid.Parse(author_id_qparam)
 		if err != nil {
-			writeServerError(w)
+			writeAsJson(
+				w,
+				ErrorResponse{
+					Error: "author_id is not valid",
+				},
+				http.StatusBadRequest)
 			return
 		}
+		author_id = uuid.NullUUID{UUID: user_id, Valid: true}
+	}
 
-		result, err := cfg.db.GetAllChirpsByUser(r.Context(), user_id)
-		if err != nil {
-			writeServerError(w)
-			return
-		}
-		chirps = result
+	var chirps []database.Chirp
+	if order == sortDesc {
+		chirps, err = cfg.db.ListChirpsDesc(r.Context(), database.ListChirpsDescParams{
+			AuthorID:        author_id,
+			BeforeCreatedAt: page.cursorCreatedAt(),
+			BeforeID:        page.cursorId(),
+			Limit:           page.queryLimit(),
+		})
 	} else {
-		result, err := cfg.db.GetAllChirps(r.Context())
-		if err != nil {
-			writeServerError(w)
-			return
-		}
-		chirps = result
+		chirps, err = cfg.db.ListChirpsAsc(r.Context(), database.ListChirpsAscParams{
+			AuthorID:       author_id,
+			AfterCreatedAt: page.cursorCreatedAt(),
+			AfterID:        page.cursorId(),
+			Limit:          page.queryLimit(),
+		})
 	}
-
-	sort_qparam := r.URL.Query().Get("sort")
-	if len(sort_qparam) > 0 {
-		switch sort_qparam {
-		case "asc":
-			sort.Slice(chirps, func(a, b int) bool {
-				return chirps[a].CreatedAt.Before(chirps[b].CreatedAt)
-			})
-		case "desc":
-			sort.Slice(chirps, func(a, b int) bool {
-				return chirps[b].CreatedAt.Before(chirps[a].CreatedAt)
-			})
-		}
+	if err != nil {
+		writeServerError(w)
+		return
 	}
 
-	chirpsResponse := make([]ChirpResponse, len(chirps))
-	for index, chirp := range chirps {
-		chirpsResponse[index] = convertChirp(chirp)
+	chirps, next_cursor := trimPage(page, chirps, chirpCursorKey)
+
+	chirpsResponse, err := cfg.convertChirps(r.Context(), cfg.viewerAccess(r), chirps)
+	if err != nil {
+		writeServerError(w)
+		return
 	}
 
 	writeAsJson(
 		w,
-		chirpsResponse,
+		ChirpsPageResponse{
+			Chirps:     chirpsResponse,
+			NextCursor: next_cursor,
+		},
 		http.StatusOK)
 }
 
@@ -198,19 +394,144 @@ func (cfg *apiConfig) getChirp(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	chirpResponse, err := cfg.convertChirp(r.Context(), cfg.viewerAccess(r), chirp)
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
 	writeAsJson(
 		w,
-		convertChirp(chirp),
+		chirpResponse,
 		http.StatusOK)
 }
 
-func convertChirp(chirp database.Chirp) ChirpResponse {
-	return ChirpResponse{
-		Id:        chirp.ID,
+// indexChirpBody records the hashtags and mentions found in the chirp body.
+func indexChirpBody(ctx context.Context, q *database.Queries, chirp database.Chirp) error {
+	err := q.TagChirp(ctx, database.TagChirpParams{
+		ChirpID: chirp.ID,
+		Tags:    chirptext.ExtractHashtags(chirp.Body),
+	})
+	if err != nil {
+		return err
+	}
+
+	return q.MentionUsers(ctx, database.MentionUsersParams{
+		ChirpID: chirp.ID,
+		Handles: chirptext.ExtractMentions(chirp.Body),
+	})
+}
+
+func (cfg *apiConfig) convertChirp(ctx context.Context, viewerId uuid.NullUUID, chirp database.Chirp) (ChirpResponse, error) {
+	chirpsResponse, err := cfg.convertChirps(ctx, viewerId, []database.Chirp{chirp})
+	if err != nil {
+		return ChirpResponse{}, err
+	}
+	return chirpsResponse[0], nil
+}
+
+// convertChirps builds the API representation of chirps, loading the
+// aggregate fields for the whole batch at once rather than per chirp.
+// Viewer specific fields are only filled in when viewerId is valid.
+func (cfg *apiConfig) convertChirps(ctx context.Context, viewerId uuid.NullUUID, chirps []database.Chirp) ([]ChirpResponse, error) {
+	chirpIds := Map(chirps, func(chirp database.Chirp) uuid.UUID {
+		return chirp.ID
+	})
+
+	replyCounts, err := cfg.db.GetReplyCounts(ctx, chirpIds)
+	if err != nil {
+		return nil, err
+	}
+
+	replyCountsById := make(map[uuid.UUID]int64, len(replyCounts))
+	for _, replyCount := range replyCounts {
+		replyCountsById[replyCount.ChirpID] = replyCount.ReplyCount
+	}
+
+	likeCounts, err := cfg.db.GetLikeCounts(ctx, chirpIds)
+	if err != nil {
+		return nil, err
+	}
+
+	likeCountsById := make(map[uuid.UUID]int64, len(likeCounts))
+	for _, likeCount := range likeCounts {
+		likeCountsById[likeCount.ChirpID] = likeCount.LikeCount
+	}
+
+	hashtags, err := cfg.db.GetChirpHashtags(ctx, chirpIds)
+	if err != nil {
+		return nil, err
+	}
+
+	hashtagsById := make(map[uuid.UUID][]string)
+	for _, hashtag := range hashtags {
+		hashtagsById[hashtag.ChirpID] = append(hashtagsById[hashtag.ChirpID], hashtag.Tag)
+	}
+
+	mentions, err := cfg.db.GetChirpMentions(ctx, chirpIds)
+	if err != nil {
+		return nil, err
+	}
+
+	mentionsById := make(map[uuid.UUID][]MentionResponse)
+	for _, mention := range mentions {
+		mentionsById[mention.ChirpID] = append(mentionsById[mention.ChirpID], MentionResponse{
+			UserId:   mention.UserID,
+			Username: mention.Username.String,
+		})
+	}
+
+	likedById := make(map[uuid.UUID]bool)
+	if viewerId.Valid {
+		likedChirpIds, err := cfg.db.GetLikedChirpIds(ctx, database.GetLikedChirpIdsParams{
+			UserID:   viewerId.UUID,
+			ChirpIds: chirpIds,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, likedChirpId := range likedChirpIds {
+			likedById[likedChirpId] = true
+		}
+	}
+
+	chirpsResponse := make([]ChirpResponse, len(chirps))
+	for index, chirp := range chirps {
+		chirpResponse := ChirpResponse{
+			Id:         chirp.ID,
+			CreatedAt:  chirp.CreatedAt,
+			UpdatedAt:  chirp.UpdatedAt,
+			Body:       chirp.Body,
+			UserId:     chirp.UserID,
+			ReplyCount: replyCountsById[chirp.ID],
+			LikeCount:  likeCountsById[chirp.ID],
+			Hashtags:   []string{},
+			Mentions:   []MentionResponse{},
+		}
+		if tags, ok := hashtagsById[chirp.ID]; ok {
+			chirpResponse.Hashtags = tags
+		}
+		if mentions, ok := mentionsById[chirp.ID]; ok {
+			chirpResponse.Mentions = mentions
+		}
+		if chirp.InReplyTo.Valid {
+			chirpResponse.InReplyTo = &chirp.InReplyTo.UUID
+		}
+		if viewerId.Valid {
+			likedByMe := likedById[chirp.ID]
+			chirpResponse.LikedByMe = &likedByMe
+		}
+		chirpsResponse[index] = chirpResponse
+	}
+
+	return chirpsResponse, nil
+}
+
+func chirpCursorKey(chirp database.Chirp) pageCursor {
+	return pageCursor{
 		CreatedAt: chirp.CreatedAt,
-		UpdatedAt: chirp.UpdatedAt,
-		Body:      chirp.Body,
-		UserId:    chirp.UserID,
+		Id:        chirp.ID,
 	}
 }
 
@@ -0,0 +1,428 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmaeagle99/chirpy/internal/database"
+)
+
+type DataExportResponse struct {
+	Id          uuid.UUID  `json:"id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	Status      string     `json:"status"`
+	CompletedAt *time.Time `json:"completed_at"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+}
+
+const (
+	dataExportLifetime     = 7 * 24 * time.Hour
+	dataExportBuildTimeout = 10 * time.Minute
+	dataExportFailTimeout  = 30 * time.Second
+	dataExportPendingIndex = "data_exports_pending_user_id_idx"
+)
+
+// requestDataExport starts building an archive of everything stored about
+// the caller. Building happens in the background; the response identifies
+// the export to fetch with getDataExport. While an export is still being
+// built, asking again returns that one.
+func (cfg *apiConfig) requestDataExport(w http.ResponseWriter, r *http.Request) {
+	userId, err := cfg.validateUserAccess(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	export, err := cfg.db.CreateDataExport(r.Context(), userId)
+	if isUniqueViolation(err, dataExportPendingIndex) {
+		export, err = cfg.db.GetPendingDataExport(r.Context(), userId)
+		if err != nil {
+			writeServerError(w)
+			return
+		}
+
+		writeAsJson(
+			w,
+			convertDataExport(export),
+			http.StatusAccepted)
+		return
+	}
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	go cfg.buildDataExport(export.ID, userId)
+
+	writeAsJson(
+		w,
+		convertDataExport(export),
+		http.StatusAccepted)
+}
+
+// getDataExport downloads a finished export as a ZIP file. Until it is
+// finished, or if building it failed, it answers with the export's status
+// instead.
+func (cfg *apiConfig) getDataExport(w http.ResponseWriter, r *http.Request) {
+	userId, err := cfg.validateUserAccess(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	exportId, err := uuid.Parse(r.PathValue("exportID"))
+	if err != nil {
+		writeAsJson(
+			w,
+			ErrorResponse{
+				Error: "exportId is not valid",
+			},
+			http.StatusBadRequest)
+		return
+	}
+
+	export, err := cfg.db.GetDataExport(r.Context(), database.GetDataExportParams{
+		ID:     exportId,
+		UserID: userId,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	switch export.Status {
+	case database.DataExportStatusPending:
+		writeAsJson(
+			w,
+			convertDataExport(export),
+			http.StatusAccepted)
+	case database.DataExportStatusReady:
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", `attachment; filename="chirpy-export.zip"`)
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(http.StatusOK)
+		w.Write(export.Archive)
+	default:
+		writeAsJson(
+			w,
+			convertDataExport(export),
+			http.StatusOK)
+	}
+}
+
+// purgeExpiredDataExports removes expired exports. Exports left pending past
+// their build timeout are marked as failed first, so the user can ask again.
+func (cfg *apiConfig) purgeExpiredDataExports(ctx context.Context) error {
+	now := time.Now().UTC()
+	_, err := cfg.db.FailStaleDataExports(ctx, database.FailStaleDataExportsParams{
+		ExpiresAt:   sql.NullTime{Time: now.Add(dataExportLifetime), Valid: true},
+		StaleBefore: now.Add(-dataExportBuildTimeout),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = cfg.db.DeleteExpiredDataExports(ctx)
+	return err
+}
+
+// buildDataExport runs outside of any request, so it records failures on
+// the export and in the log.
+func (cfg *apiConfig) buildDataExport(exportId uuid.UUID, userId uuid.UUID) {
+	ctx, cancel := context.WithTimeout(context.Background(), dataExportBuildTimeout)
+	defer cancel()
+
+	expiresAt := sql.NullTime{Time: time.Now().UTC().Add(dataExportLifetime), Valid: true}
+
+	archive, err := cfg.writeDataExportArchive(ctx, userId)
+	if err == nil {
+		err = cfg.db.CompleteDataExport(ctx, database.CompleteDataExportParams{
+			ID:        exportId,
+			Archive:   archive,
+			ExpiresAt: expiresAt,
+		})
+	}
+	if err == nil {
+		return
+	}
+
+	log.Printf("building data export %s: %v", exportId, err)
+
+	// The build may have failed because ctx ran out, so recording the failure
+	// gets its own deadline.
+	failCtx, failCancel := context.WithTimeout(context.Background(), dataExportFailTimeout)
+	defer failCancel()
+
+	err = cfg.db.FailDataExport(failCtx, database.FailDataExportParams{
+		ID:        exportId,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		log.Printf("marking data export %s as failed: %v", exportId, err)
+	}
+}
+
+type exportProfile struct {
+	Id               uuid.UUID  `json:"id"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+	Email            string     `json:"email"`
+	EmailVerifiedAt  *time.Time `json:"email_verified_at"`
+	Username         *string    `json:"username"`
+	DisplayName      string     `json:"display_name"`
+	Bio              string     `json:"bio"`
+	Role             string     `json:"role"`
+	TwoFactorEnabled bool       `json:"two_factor_enabled"`
+	DeleteAfter      *time.Time `json:"delete_after"`
+}
+
+type exportSubscriptions struct {
+	IsChirpyRed bool                 `json:"is_chirpy_red"`
+	History     []exportSubscription `json:"history"`
+}
+
+type exportSubscription struct {
+	CreatedAt        time.Time  `json:"created_at"`
+	Plan             string     `json:"plan"`
+	Status           string     `json:"status"`
+	CurrentPeriodEnd *time.Time `json:"current_period_end"`
+	CanceledAt       *time.Time `json:"canceled_at"`
+}
+
+type exportChirp struct {
+	Id        uuid.UUID        `json:"id"`
+	CreatedAt time.Time        `json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
+	Body      string           `json:"body"`
+	InReplyTo *uuid.UUID       `json:"in_reply_to"`
+	Revisions []exportRevision `json:"revisions"`
+}
+
+type exportRevision struct {
+	CreatedAt time.Time `json:"created_at"`
+	Body      string    `json:"body"`
+}
+
+type exportLike struct {
+	ChirpId   uuid.UUID `json:"chirp_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type exportFollow struct {
+	FollowerId uuid.UUID `json:"follower_id"`
+	FolloweeId uuid.UUID `json:"followee_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type exportSession struct {
+	Id        uuid.UUID  `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	UserAgent string     `json:"user_agent"`
+	IpAddress string     `json:"ip_address"`
+}
+
+// writeDataExportArchive collects the user's data into a ZIP file with one
+// JSON document per kind of data. Secrets such as password and token hashes
+// are left out.
+func (cfg *apiConfig) writeDataExportArchive(ctx context.Context, userId uuid.UUID) ([]byte, error) {
+	user, err := cfg.db.GetUserById(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	isChirpyRed, err := cfg.db.IsChirpyRed(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	subscriptions, err := cfg.db.ListSubscriptionsByUser(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	chirps, err := cfg.db.ListAllChirpsByUser(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions, err := cfg.db.ListChirpRevisionsByUser(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	likes, err := cfg.db.ListChirpLikesByUser(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	follows, err := cfg.db.ListFollowsByUser(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshTokens, err := cfg.db.ListRefreshTokensByUser(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	revisionsByChirp := map[uuid.UUID][]exportRevision{}
+	for _, revision := range revisions {
+		revisionsByChirp[revision.ChirpID] = append(revisionsByChirp[revision.ChirpID], exportRevision{
+			CreatedAt: revision.CreatedAt,
+			Body:      revision.Body,
+		})
+	}
+
+	exportChirps := Map(chirps, func(chirp database.Chirp) exportChirp {
+		exported := exportChirp{
+			Id:        chirp.ID,
+			CreatedAt: chirp.CreatedAt,
+			UpdatedAt: chirp.UpdatedAt,
+			Body:      chirp.Body,
+			Revisions: revisionsByChirp[chirp.ID],
+		}
+		if chirp.InReplyTo.Valid {
+			exported.InReplyTo = &chirp.InReplyTo.UUID
+		}
+		if exported.Revisions == nil {
+			exported.Revisions = []exportRevision{}
+		}
+		return exported
+	})
+
+	// Refresh tokens are rotated on every use, so only the latest token of
+	// each family describes the session.
+	sessions := []exportSession{}
+	sessionIndex := map[uuid.UUID]int{}
+	for _, token := range refreshTokens {
+		session := exportSession{
+			Id:        token.FamilyID,
+			CreatedAt: token.CreatedAt,
+			ExpiresAt: token.ExpiresAt,
+			RevokedAt: nullTimePtr(token.RevokedAt),
+			UserAgent: token.UserAgent,
+			IpAddress: token.IpAddress,
+		}
+
+		index, ok := sessionIndex[token.FamilyID]
+		if !ok {
+			sessionIndex[token.FamilyID] = len(sessions)
+			sessions = append(sessions, session)
+			continue
+		}
+
+		session.CreatedAt = sessions[index].CreatedAt
+		sessions[index] = session
+	}
+
+	files := []struct {
+		name    string
+		content any
+	}{
+		{
+			name: "profile.json",
+			content: exportProfile{
+				Id:               user.ID,
+				CreatedAt:        user.CreatedAt,
+				UpdatedAt:        user.UpdatedAt,
+				Email:            user.Email,
+				EmailVerifiedAt:  nullTimePtr(user.EmailVerifiedAt),
+				Username:         nullStringPtr(user.Username),
+				DisplayName:      user.DisplayName,
+				Bio:              user.Bio,
+				Role:             string(user.Role),
+				TwoFactorEnabled: user.TotpEnabledAt.Valid,
+				DeleteAfter:      nullTimePtr(user.DeleteAfter),
+			},
+		},
+		{
+			name: "subscription.json",
+			content: exportSubscriptions{
+				IsChirpyRed: isChirpyRed,
+				History: Map(subscriptions, func(subscription database.Subscription) exportSubscription {
+					return exportSubscription{
+						CreatedAt:        subscription.CreatedAt,
+						Plan:             subscription.Plan,
+						Status:           string(subscription.Status),
+						CurrentPeriodEnd: nullTimePtr(subscription.CurrentPeriodEnd),
+						CanceledAt:       nullTimePtr(subscription.CanceledAt),
+					}
+				}),
+			},
+		},
+		{
+			name:    "chirps.json",
+			content: exportChirps,
+		},
+		{
+			name: "likes.json",
+			content: Map(likes, func(like database.ChirpLike) exportLike {
+				return exportLike{
+					ChirpId:   like.ChirpID,
+					CreatedAt: like.CreatedAt,
+				}
+			}),
+		},
+		{
+			name: "follows.json",
+			content: Map(follows, func(follow database.Follow) exportFollow {
+				return exportFollow{
+					FollowerId: follow.FollowerID,
+					FolloweeId: follow.FolloweeID,
+					CreatedAt:  follow.CreatedAt,
+				}
+			}),
+		},
+		{
+			name:    "sessions.json",
+			content: sessions,
+		},
+	}
+
+	var buffer bytes.Buffer
+	archive := zip.NewWriter(&buffer)
+	for _, file := range files {
+		writer, err := archive.Create(file.name)
+		if err != nil {
+			return nil, err
+		}
+
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(file.content)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = archive.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func convertDataExport(export database.DataExport) DataExportResponse {
+	return DataExportResponse{
+		Id:          export.ID,
+		CreatedAt:   export.CreatedAt,
+		Status:      string(export.Status),
+		CompletedAt: nullTimePtr(export.CompletedAt),
+		ExpiresAt:   nullTimePtr(export.ExpiresAt),
+	}
+}
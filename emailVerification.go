@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmaeagle99/chirpy/internal/auth"
+	"github.com/jmaeagle99/chirpy/internal/database"
+	"github.com/jmaeagle99/chirpy/internal/mail"
+)
+
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+const (
+	emailVerificationTokenLifetime = 24 * time.Hour
+	emailUniqueConstraint          = "users_email_key"
+)
+
+var (
+	errVerificationTokenInvalid = errors.New("verification token is not valid or has expired")
+	errEmailAlreadyVerified     = errors.New("email address is already verified")
+)
+
+// verifyEmail confirms an address with a token from a verification mail. For
+// an email change this is the point where the login email actually changes.
+func (cfg *apiConfig) verifyEmail(w http.ResponseWriter, r *http.Request) {
+	request := VerifyEmailRequest{}
+
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&request)
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	var user database.User
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		token, err := q.UseEmailVerificationToken(r.Context(), auth.HashToken(request.Token))
+		if errors.Is(err, sql.ErrNoRows) {
+			return errVerificationTokenInvalid
+		}
+		if err != nil {
+			return err
+		}
+
+		user, err = q.VerifyEmail(r.Context(), database.VerifyEmailParams{
+			ID:    token.UserID,
+			Email: token.Email,
+		})
+		if err != nil {
+			return err
+		}
+
+		return q.ExpireEmailVerificationTokens(r.Context(), token.UserID)
+	})
+	if errors.Is(err, errVerificationTokenInvalid) {
+		writeAsJson(
+			w,
+			ErrorResponse{
+				Error: err.Error(),
+			},
+			http.StatusBadRequest)
+		return
+	}
+	if isUniqueViolation(err, emailUniqueConstraint) {
+		writeAsJson(
+			w,
+			ErrorResponse{
+				Error: "email address is already in use",
+			},
+			http.StatusConflict)
+		return
+	}
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	cfg.writeUser(w, r, user, "", "", http.StatusOK)
+}
+
+// resendEmailVerification mails a new token for the caller's current address,
+// for when the first one expired or never arrived.
+func (cfg *apiConfig) resendEmailVerification(w http.ResponseWriter, r *http.Request) {
+	userId, err := cfg.validateUserAccess(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	user, err := cfg.db.GetUserById(r.Context(), userId)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	if user.EmailVerifiedAt.Valid {
+		writeAsJson(
+			w,
+			ErrorResponse{
+				Error: errEmailAlreadyVerified.Error(),
+			},
+			http.StatusConflict)
+		return
+	}
+
+	verification_token, err := issueEmailVerificationToken(r.Context(), cfg.db, user.ID, user.Email)
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	cfg.sendEmailVerification(user.Email, verification_token)
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func issueEmailVerificationToken(ctx context.Context, q *database.Queries, userId uuid.UUID, email string) (string, error) {
+	verification_token, err := auth.MakeRefreshToken()
+	if err != nil {
+		return "", err
+	}
+
+	err = q.CreateEmailVerificationToken(ctx, database.CreateEmailVerificationTokenParams{
+		TokenHash: auth.HashToken(verification_token),
+		UserID:    userId,
+		Email:     email,
+		ExpiresAt: time.Now().UTC().Add(emailVerificationTokenLifetime),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return verification_token, nil
+}
+
+func (cfg *apiConfig) sendEmailVerification(email string, verification_token string) {
+	go cfg.sendMail(mail.Message{
+		To:      email,
+		Subject: "Verify your Chirpy email address",
+		Body: fmt.Sprintf(
+			"Confirm that this address belongs to your Chirpy account.\n\n"+
+				"Verification token: %s\n\n"+
+				"The token expires in %v. "+
+				"If you did not sign up or change your email, you can ignore this message.\n",
+			verification_token,
+			emailVerificationTokenLifetime),
+	})
+}
+
+// requireVerifiedEmail enforces the REQUIRE_VERIFIED_EMAIL policy, writing a
+// response and returning false when the user may not proceed.
+func (cfg *apiConfig) requireVerifiedEmail(w http.ResponseWriter, r *http.Request, userId uuid.UUID) bool {
+	if !cfg.verifiedEmailRequired {
+		return true
+	}
+
+	user, err := cfg.db.GetUserById(r.Context(), userId)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return false
+	}
+
+	if !user.EmailVerifiedAt.Valid {
+		writeAsJson(
+			w,
+			ErrorResponse{
+				Error: "email address must be verified first",
+			},
+			http.StatusForbidden)
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmaeagle99/chirpy/internal/database"
+)
+
+type FollowResponse struct {
+	UserId     uuid.UUID `json:"user_id"`
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+type FollowsPageResponse struct {
+	Users      []FollowResponse `json:"users"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+}
+
+func (cfg *apiConfig) followUser(w http.ResponseWriter, r *http.Request) {
+	userId, err := cfg.validateUserAccess(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	followeeId, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		writeAsJson(
+			w,
+			ErrorResponse{
+				Error: "userId is not valid",
+			},
+			http.StatusBadRequest)
+		return
+	}
+
+	if followeeId == userId {
+		writeAsJson(
+			w,
+			ErrorResponse{
+				Error: "Users cannot follow themselves",
+			},
+			http.StatusBadRequest)
+		return
+	}
+
+	_, err = cfg.db.GetUserById(r.Context(), followeeId)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	err = cfg.db.FollowUser(r.Context(), database.FollowUserParams{
+		FollowerID: userId,
+		FolloweeID: followeeId,
+	})
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) unfollowUser(w http.ResponseWriter, r *http.Request) {
+	userId, err := cfg.validateUserAccess(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	followeeId, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		writeAsJson(
+			w,
+			ErrorResponse{
+				Error: "userId is not valid",
+			},
+			http.StatusBadRequest)
+		return
+	}
+
+	err = cfg.db.UnfollowUser(r.Context(), database.UnfollowUserParams{
+		FollowerID: userId,
+		FolloweeID: followeeId,
+	})
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) getFollowers(w http.ResponseWriter, r *http.Request) {
+	userId, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		writeAsJson(
+			w,
+			ErrorResponse{
+				Error: "userId is not valid",
+			},
+			http.StatusBadRequest)
+		return
+	}
+
+	page, err := parsePageRequest(r, sortDesc)
+	if err != nil {
+		writeAsJson(
+			w,
+			ErrorResponse{
+				Error: err.Error(),
+			},
+			http.StatusBadRequest)
+		return
+	}
+
+	followers, err := cfg.db.ListFollowers(r.Context(), database.ListFollowersParams{
+		UserID:          userId,
+		BeforeCreatedAt: page.cursorCreatedAt(),
+		BeforeID:        page.cursorId(),
+		Limit:           page.queryLimit(),
+	})
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	followers, next_cursor := trimPage(page, followers, func(follower database.ListFollowersRow) pageCursor {
+		return pageCursor{
+			CreatedAt: follower.CreatedAt,
+			Id:        follower.UserID,
+		}
+	})
+
+	writeAsJson(
+		w,
+		FollowsPageResponse{
+			Users: Map(followers, func(follower database.ListFollowersRow) FollowResponse {
+				return FollowResponse{
+					UserId:     follower.UserID,
+					FollowedAt: follower.CreatedAt,
+				}
+			}),
+			NextCursor: next_cursor,
+		},
+		http.StatusOK)
+}
+
+func (cfg *apiConfig) getFollowing(w http.ResponseWriter, r *http.Request) {
+	userId, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		writeAsJson(
+			w,
+			ErrorResponse{
+				Error: "userId is not valid",
+			},
+			http.StatusBadRequest)
+		return
+	}
+
+	page, err := parsePageRequest(r, sortDesc)
+	if err != nil {
+		writeAsJson(
+			w,
+			ErrorResponse{
+				Error: err.Error(),
+			},
+			http.StatusBadRequest)
+		return
+	}
+
+	following, err := cfg.db.ListFollowing(r.Context(), database.ListFollowingParams{
+		UserID:          userId,
+		BeforeCreatedAt: page.cursorCreatedAt(),
+		BeforeID:        page.cursorId(),
+		Limit:           page.queryLimit(),
+	})
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	following, next_cursor := trimPage(page, following, func(followee database.ListFollowingRow) pageCursor {
+		return pageCursor{
+			CreatedAt: followee.CreatedAt,
+			Id:        followee.UserID,
+		}
+	})
+
+	writeAsJson(
+		w,
+		FollowsPageResponse{
+			Users: Map(following, func(followee database.ListFollowingRow) FollowResponse {
+				return FollowResponse{
+					UserId:     followee.UserID,
+					FollowedAt: followee.CreatedAt,
+				}
+			}),
+			NextCursor: next_cursor,
+		},
+		http.StatusOK)
+}
+
+func (cfg *apiConfig) getTimeline(w http.ResponseWriter, r *http.Request) {
+	userId, err := cfg.validateUserAccess(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	page, err := parsePageRequest(r, sortDesc)
+	if err != nil {
+		writeAsJson(
+			w,
+			ErrorResponse{
+				Error: err.Error(),
+			},
+			http.StatusBadRequest)
+		return
+	}
+
+	chirps, err := cfg.db.ListTimeline(r.Context(), database.ListTimelineParams{
+		FollowerID:      userId,
+		BeforeCreatedAt: page.cursorCreatedAt(),
+		BeforeID:        page.cursorId(),
+		Limit:           page.queryLimit(),
+	})
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	chirps, next_cursor := trimPage(page, chirps, chirpCursorKey)
+
+	chirpsResponse, err := cfg.convertChirps(r.Context(), uuid.NullUUID{UUID: userId, Valid: true}, chirps)
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	writeAsJson(
+		w,
+		ChirpsPageResponse{
+			Chirps:     chirpsResponse,
+			NextCursor: next_cursor,
+		},
+		http.StatusOK)
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jmaeagle99/chirpy/internal/chirptext"
+	"github.com/jmaeagle99/chirpy/internal/database"
+)
+
+const (
+	defaultTrendingWindow = 24 * time.Hour
+	maxTrendingWindow     = 30 * 24 * time.Hour
+	defaultTrendingLimit  = 10
+)
+
+type TrendingHashtagResponse struct {
+	Tag        string `json:"tag"`
+	ChirpCount int64  `json:"chirp_count"`
+}
+
+func (cfg *apiConfig) getHashtagChirps(w http.ResponseWriter, r *http.Request) {
+	tag := chirptext.NormalizeHashtag(r.PathValue("tag"))
+
+	page, err := parsePageRequest(r, sortDesc)
+	if err != nil {
+		writeAsJson(
+			w,
+			ErrorResponse{
+				Error: err.Error(),
+			},
+			http.StatusBadRequest)
+		return
+	}
+
+	chirps, err := cfg.db.ListHashtagChirps(r.Context(), database.ListHashtagChirpsParams{
+		Tag:             tag,
+		BeforeCreatedAt: page.cursorCreatedAt(),
+		BeforeID:        page.cursorId(),
+		Limit:           page.queryLimit(),
+	})
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	chirps, next_cursor := trimPage(page, chirps, chirpCursorKey)
+
+	chirpsResponse, err := cfg.convertChirps(r.Context(), cfg.viewerAccess(r), chirps)
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	writeAsJson(
+		w,
+		ChirpsPageResponse{
+			Chirps:     chirpsResponse,
+			NextCursor: next_cursor,
+		},
+		http.StatusOK)
+}
+
+// getTrendingHashtags ranks hashtags by how many chirps used them within the
+// trailing window, e.g. ?window=6h, which defaults to the last day.
+func (cfg *apiConfig) getTrendingHashtags(w http.ResponseWriter, r *http.Request) {
+	window := defaultTrendingWindow
+	window_qparam := r.URL.Query().Get("window")
+	if len(window_qparam) > 0 {
+		value, err := time.ParseDuration(window_qparam)
+		if err != nil || value <= 0 || value > maxTrendingWindow {
+			writeAsJson(
+				w,
+				ErrorResponse{
+					Error: fmt.Sprintf("window must be a duration up to %s", maxTrendingWindow),
+				},
+				http.StatusBadRequest)
+			return
+		}
+		window = value
+	}
+
+	limit := defaultTrendingLimit
+	limit_qparam := r.URL.Query().Get("limit")
+	if len(limit_qparam) > 0 {
+		value, err := strconv.Atoi(limit_qparam)
+		if err != nil || value < 1 || value > maxPageLimit {
+			writeAsJson(
+				w,
+				ErrorResponse{
+					Error: fmt.Sprintf("limit must be between 1 and %d", maxPageLimit),
+				},
+				http.StatusBadRequest)
+			return
+		}
+		limit = value
+	}
+
+	trending, err := cfg.db.GetTrendingHashtags(r.Context(), database.GetTrendingHashtagsParams{
+		Since: time.Now().UTC().Add(-window),
+		Limit: int32(limit),
+	})
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	writeAsJson(
+		w,
+		Map(trending, func(hashtag database.GetTrendingHashtagsRow) TrendingHashtagResponse {
+			return TrendingHashtagResponse{
+				Tag:        hashtag.Tag,
+				ChirpCount: hashtag.ChirpCount,
+			}
+		}),
+		http.StatusOK)
+}
@@ -76,27 +76,26 @@ func TestValidateJWT(t *testing.T) {
 	defaultUserId := uuid.MustParse("b92b8014-6b95-42df-99d6-1e78551bb6ea")
 	defaultTokenSecret := "SGVsbG8sIFdvcmxkIQ=="
 	defaultExpiry, _ := time.ParseDuration("5s")
-	jwt1, _ := MakeJWT(defaultUserId, defaultTokenSecret, defaultExpiry)
-	jwt2, _ := MakeJWT(defaultUserId, "VW5leHBlY3RlZCB0b2tlbiBzZWNyZXQ=", defaultExpiry)
+	ring, _ := NewSecretKeyRing(defaultTokenSecret)
+	otherRing, _ := NewSecretKeyRing("VW5leHBlY3RlZCB0b2tlbiBzZWNyZXQ=")
+	jwt1, _ := ring.MakeJWT(defaultUserId, "user", defaultExpiry)
+	jwt2, _ := otherRing.MakeJWT(defaultUserId, "user", defaultExpiry)
 
 	tests := []struct {
 		name           string
 		tokenString    string
-		decodeSecret   string
 		expectedError  bool
 		expectedUserId uuid.UUID
 	}{
 		{
 			name:           "Valid token with expected subject",
 			tokenString:    jwt1,
-			decodeSecret:   defaultTokenSecret,
 			expectedError:  false,
 			expectedUserId: defaultUserId,
 		},
 		{
 			name:           "Mismatched signature",
 			tokenString:    jwt2,
-			decodeSecret:   defaultTokenSecret,
 			expectedError:  true,
 			expectedUserId: uuid.Nil,
 		},
@@ -104,7 +103,7 @@ func TestValidateJWT(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			actualUserId, err := ValidateJWT(tt.tokenString, tt.decodeSecret)
+			actualUserId, err := ring.ValidateJWT(tt.tokenString)
 			if (err != nil) != tt.expectedError {
 				t.Errorf("ValidateJWT() error = %v, expectedError %v", err, tt.expectedError)
 			}
@@ -115,6 +114,49 @@ func TestValidateJWT(t *testing.T) {
 	}
 }
 
+func TestParseJWT(t *testing.T) {
+	defaultUserId := uuid.MustParse("b92b8014-6b95-42df-99d6-1e78551bb6ea")
+	defaultTokenSecret := "SGVsbG8sIFdvcmxkIQ=="
+	defaultExpiry, _ := time.ParseDuration("5s")
+	ring, _ := NewSecretKeyRing(defaultTokenSecret)
+
+	tests := []struct {
+		name          string
+		role          string
+		expectedError bool
+		expectedRole  string
+	}{
+		{
+			name:          "Admin role claim",
+			role:          "admin",
+			expectedError: false,
+			expectedRole:  "admin",
+		},
+		{
+			name:          "Empty role claim",
+			role:          "",
+			expectedError: false,
+			expectedRole:  "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokenString, _ := ring.MakeJWT(defaultUserId, tt.role, defaultExpiry)
+			claims, err := ring.ParseJWT(tokenString)
+			if (err != nil) != tt.expectedError {
+				t.Errorf("ParseJWT() error = %v, expectedError %v", err, tt.expectedError)
+			}
+			if claims.Role != tt.expectedRole {
+				t.Errorf("ParseJWT() expects role %v, got %v", tt.expectedRole, claims.Role)
+			}
+			if claims.Subject != defaultUserId.String() {
+				t.Errorf("ParseJWT() expects subject %v, got %v", defaultUserId, claims.Subject)
+			}
+		})
+	}
+}
+
 func TestGetBearerToken(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -166,3 +208,34 @@ func TestGetBearerToken(t *testing.T) {
 		})
 	}
 }
+
+func TestHashToken(t *testing.T) {
+	tests := []struct {
+		name          string
+		token         string
+		expectedValue string
+	}{
+		{
+			name:          "Known digest",
+			token:         "abc",
+			expectedValue: "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad",
+		},
+		{
+			name:          "Hex encoded token",
+			token:         "0f1e2d3c4b5a69788796a5b4c3d2e1f00f1e2d3c4b5a69788796a5b4c3d2e1f0",
+			expectedValue: "331ab04caa328927f706627b812f4139f9ec42a6d61f17e468a70c41a48d8f67",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actualValue := HashToken(tt.token)
+			if actualValue != tt.expectedValue {
+				t.Errorf("HashToken() expects %v, got %v", tt.expectedValue, actualValue)
+			}
+			if actualValue == tt.token {
+				t.Errorf("HashToken() returned the token unchanged")
+			}
+		})
+	}
+}
@@ -0,0 +1,362 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const minRSAKeyBits = 2048
+
+// Issue times keep microseconds, like the timestamps they are compared with
+// in the database, so tokens issued right after a logout are told apart from
+// those issued before it.
+func init() {
+	jwt.TimePrecision = time.Microsecond
+}
+
+type verificationKey struct {
+	method jwt.SigningMethod
+	key    interface{}
+}
+
+type signingKey struct {
+	id     string
+	method jwt.SigningMethod
+	key    interface{}
+}
+
+// KeyRing signs access tokens with a single active key and verifies tokens
+// signed by any of its keys, which lets keys be rotated without invalidating
+// tokens that are still in flight.
+type KeyRing struct {
+	signing      signingKey
+	verification map[string]verificationKey
+	legacySecret []byte
+}
+
+// JWK is the public part of a verification key as published in a JWKS.
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyId     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// NewSecretKeyRing creates a key ring that signs and verifies HS256 tokens
+// with a base64 encoded shared secret. It publishes no keys.
+func NewSecretKeyRing(tokenSecret string) (*KeyRing, error) {
+	secret, err := decodeTokenSecret(tokenSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	return &KeyRing{
+		signing: signingKey{
+			method: jwt.SigningMethodHS256,
+			key:    secret,
+		},
+		verification: map[string]verificationKey{},
+		legacySecret: secret,
+	}, nil
+}
+
+// LoadKeyRing reads every *.pem file in dir. The file name without its
+// extension is the key id. Private keys (PKCS#8, or PKCS#1 for RSA) can sign
+// and verify, while public keys (PKIX) can only verify. Ed25519 keys sign
+// with EdDSA and RSA keys with RS256.
+//
+// The private key named by signingKeyId signs new tokens. When it is empty
+// the private key whose id sorts last is used, so naming keys by creation
+// date makes the newest key active.
+func LoadKeyRing(dir string, signingKeyId string) (*KeyRing, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	ring := &KeyRing{
+		verification: map[string]verificationKey{},
+	}
+	signers := map[string]signingKey{}
+	lastSignerId := ""
+
+	for _, path := range paths {
+		keyId := strings.TrimSuffix(filepath.Base(path), ".pem")
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		private, public, err := parsePEMKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", keyId, err)
+		}
+
+		method, err := signingMethodFor(public)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", keyId, err)
+		}
+
+		ring.verification[keyId] = verificationKey{
+			method: method,
+			key:    public,
+		}
+
+		if private != nil {
+			signers[keyId] = signingKey{
+				id:     keyId,
+				method: method,
+				key:    private,
+			}
+			lastSignerId = keyId
+		}
+	}
+
+	if len(signingKeyId) == 0 {
+		signingKeyId = lastSignerId
+	}
+
+	signer, ok := signers[signingKeyId]
+	if !ok {
+		return nil, fmt.Errorf("no private signing key found in %s", dir)
+	}
+	ring.signing = signer
+
+	return ring, nil
+}
+
+// AcceptLegacySecret additionally accepts HS256 tokens without a key id that
+// were signed with the base64 encoded tokenSecret, for use while migrating
+// away from shared secret signing.
+func (ring *KeyRing) AcceptLegacySecret(tokenSecret string) error {
+	secret, err := decodeTokenSecret(tokenSecret)
+	if err != nil {
+		return err
+	}
+
+	ring.legacySecret = secret
+	return nil
+}
+
+func (ring *KeyRing) MakeJWT(userID uuid.UUID, role string, expiresIn time.Duration) (string, error) {
+	now := time.Now().UTC()
+	return ring.sign(Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "chirpy",
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(expiresIn)),
+			Subject:   userID.String(),
+		},
+		Role: role,
+	})
+}
+
+// ParseJWT parses an access token. Other tokens signed by the ring, such as
+// MFA challenges, carry an audience and are rejected here.
+func (ring *KeyRing) ParseJWT(tokenString string) (Claims, error) {
+	claims, err := ring.parse(tokenString)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	if len(claims.Audience) > 0 {
+		return Claims{}, fmt.Errorf("token is not an access token")
+	}
+
+	return claims, nil
+}
+
+const mfaAudience = "chirpy-mfa"
+
+// MakeMFAToken returns a token proving that userID passed the password step
+// of a login, to be exchanged for access and refresh tokens together with a
+// second factor.
+func (ring *KeyRing) MakeMFAToken(userID uuid.UUID, expiresIn time.Duration) (string, error) {
+	now := time.Now().UTC()
+	return ring.sign(Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "chirpy",
+			Audience:  jwt.ClaimStrings{mfaAudience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(expiresIn)),
+			Subject:   userID.String(),
+		},
+	})
+}
+
+func (ring *KeyRing) ValidateMFAToken(tokenString string) (uuid.UUID, error) {
+	claims, err := ring.parse(tokenString, jwt.WithAudience(mfaAudience))
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return uuid.Parse(claims.Subject)
+}
+
+func (ring *KeyRing) sign(claims Claims) (string, error) {
+	token := jwt.NewWithClaims(ring.signing.method, claims)
+
+	if len(ring.signing.id) > 0 {
+		token.Header["kid"] = ring.signing.id
+	}
+
+	return token.SignedString(ring.signing.key)
+}
+
+func (ring *KeyRing) parse(tokenString string, options ...jwt.ParserOption) (Claims, error) {
+	claims := Claims{}
+
+	options = append(
+		options,
+		jwt.WithIssuer("chirpy"),
+		jwt.WithValidMethods([]string{
+			jwt.SigningMethodEdDSA.Alg(),
+			jwt.SigningMethodRS256.Alg(),
+			jwt.SigningMethodHS256.Alg(),
+		}))
+
+	_, err := jwt.ParseWithClaims(tokenString, &claims, ring.keyFunc, options...)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	return claims, nil
+}
+
+func (ring *KeyRing) ValidateJWT(tokenString string) (uuid.UUID, error) {
+	claims, err := ring.ParseJWT(tokenString)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return uuid.Parse(claims.Subject)
+}
+
+func (ring *KeyRing) keyFunc(token *jwt.Token) (interface{}, error) {
+	keyId, hasKeyId := token.Header["kid"].(string)
+	if !hasKeyId {
+		if token.Method == jwt.SigningMethodHS256 && ring.legacySecret != nil {
+			return ring.legacySecret, nil
+		}
+		return nil, fmt.Errorf("token has no key id")
+	}
+
+	key, ok := ring.verification[keyId]
+	if !ok {
+		return nil, fmt.Errorf("token key id %q is not known", keyId)
+	}
+
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("token algorithm %s does not match key %q", token.Method.Alg(), keyId)
+	}
+
+	return key.key, nil
+}
+
+// JWKS returns the public verification keys sorted by key id.
+func (ring *KeyRing) JWKS() JWKS {
+	keyIds := make([]string, 0, len(ring.verification))
+	for keyId := range ring.verification {
+		keyIds = append(keyIds, keyId)
+	}
+	sort.Strings(keyIds)
+
+	jwks := JWKS{
+		Keys: []JWK{},
+	}
+	for _, keyId := range keyIds {
+		key := ring.verification[keyId]
+		jwk := JWK{
+			KeyId:     keyId,
+			Use:       "sig",
+			Algorithm: key.method.Alg(),
+		}
+
+		switch public := key.key.(type) {
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		}
+
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	return jwks
+}
+
+func parsePEMKey(data []byte) (crypto.Signer, crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, nil, fmt.Errorf("no PEM block found")
+	}
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, nil, err
+		}
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, nil, fmt.Errorf("private key type %T is not supported", key)
+		}
+		return signer, signer.Public(), nil
+	case "RSA PRIVATE KEY":
+		key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, nil, err
+		}
+		return key, key.Public(), nil
+	case "PUBLIC KEY":
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, key, nil
+	default:
+		return nil, nil, fmt.Errorf("PEM block type %q is not supported", block.Type)
+	}
+}
+
+func signingMethodFor(public crypto.PublicKey) (jwt.SigningMethod, error) {
+	switch key := public.(type) {
+	case ed25519.PublicKey:
+		return jwt.SigningMethodEdDSA, nil
+	case *rsa.PublicKey:
+		if key.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("RSA keys must be at least %d bits", minRSAKeyBits)
+		}
+		return jwt.SigningMethodRS256, nil
+	default:
+		return nil, fmt.Errorf("key type %T is not supported", public)
+	}
+}
@@ -0,0 +1,313 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func writePrivateKey(t *testing.T, dir, keyId string, key interface{}) {
+	t.Helper()
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalPKCS8PrivateKey() error = %v", err)
+	}
+	writePEM(t, dir, keyId, "PRIVATE KEY", der)
+}
+
+func writePublicKey(t *testing.T, dir, keyId string, key interface{}) {
+	t.Helper()
+
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		t.Fatalf("MarshalPKIXPublicKey() error = %v", err)
+	}
+	writePEM(t, dir, keyId, "PUBLIC KEY", der)
+}
+
+func writePEM(t *testing.T, dir, keyId, blockType string, der []byte) {
+	t.Helper()
+
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	err := os.WriteFile(filepath.Join(dir, keyId+".pem"), data, 0600)
+	if err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+}
+
+func TestKeyRing(t *testing.T) {
+	defaultUserId := uuid.MustParse("b92b8014-6b95-42df-99d6-1e78551bb6ea")
+	defaultExpiry, _ := time.ParseDuration("5s")
+
+	_, oldEd25519Key, _ := ed25519.GenerateKey(rand.Reader)
+	_, newEd25519Key, _ := ed25519.GenerateKey(rand.Reader)
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	otherPublicKey, _, _ := ed25519.GenerateKey(rand.Reader)
+
+	oldDir := t.TempDir()
+	writePrivateKey(t, oldDir, "2026-01-01", oldEd25519Key)
+	oldRing, err := LoadKeyRing(oldDir, "")
+	if err != nil {
+		t.Fatalf("LoadKeyRing() error = %v", err)
+	}
+
+	dir := t.TempDir()
+	writePublicKey(t, dir, "2026-01-01", oldEd25519Key.Public())
+	writePrivateKey(t, dir, "2026-02-01", rsaKey)
+	writePrivateKey(t, dir, "2026-03-01", newEd25519Key)
+	writePublicKey(t, dir, "external", otherPublicKey)
+	ring, err := LoadKeyRing(dir, "")
+	if err != nil {
+		t.Fatalf("LoadKeyRing() error = %v", err)
+	}
+
+	rsaRing, err := LoadKeyRing(dir, "2026-02-01")
+	if err != nil {
+		t.Fatalf("LoadKeyRing() error = %v", err)
+	}
+
+	unknownDir := t.TempDir()
+	writePrivateKey(t, unknownDir, "unknown", newEd25519Key)
+	unknownRing, _ := LoadKeyRing(unknownDir, "")
+
+	secretRing, _ := NewSecretKeyRing("SGVsbG8sIFdvcmxkIQ==")
+	legacyRing, _ := LoadKeyRing(dir, "")
+	legacyRing.AcceptLegacySecret("SGVsbG8sIFdvcmxkIQ==")
+
+	tests := []struct {
+		name          string
+		signer        *KeyRing
+		verifier      *KeyRing
+		expectedError bool
+	}{
+		{
+			name:          "Newest private key signs",
+			signer:        ring,
+			verifier:      ring,
+			expectedError: false,
+		},
+		{
+			name:          "RSA signing key",
+			signer:        rsaRing,
+			verifier:      ring,
+			expectedError: false,
+		},
+		{
+			name:          "Rotated key still verifies",
+			signer:        oldRing,
+			verifier:      ring,
+			expectedError: false,
+		},
+		{
+			name:          "Unknown key id",
+			signer:        unknownRing,
+			verifier:      ring,
+			expectedError: true,
+		},
+		{
+			name:          "Shared secret token rejected",
+			signer:        secretRing,
+			verifier:      ring,
+			expectedError: true,
+		},
+		{
+			name:          "Shared secret token accepted as legacy",
+			signer:        secretRing,
+			verifier:      legacyRing,
+			expectedError: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokenString, err := tt.signer.MakeJWT(defaultUserId, "user", defaultExpiry)
+			if err != nil {
+				t.Fatalf("MakeJWT() error = %v", err)
+			}
+
+			actualUserId, err := tt.verifier.ValidateJWT(tokenString)
+			if (err != nil) != tt.expectedError {
+				t.Errorf("ValidateJWT() error = %v, expectedError %v", err, tt.expectedError)
+			}
+			if !tt.expectedError && actualUserId != defaultUserId {
+				t.Errorf("ValidateJWT() expects %v, got %v", defaultUserId, actualUserId)
+			}
+		})
+	}
+}
+
+func TestKeyRingMFAToken(t *testing.T) {
+	defaultUserId := uuid.MustParse("b92b8014-6b95-42df-99d6-1e78551bb6ea")
+	defaultExpiry, _ := time.ParseDuration("5s")
+
+	ring, _ := NewSecretKeyRing("SGVsbG8sIFdvcmxkIQ==")
+	mfaToken, _ := ring.MakeMFAToken(defaultUserId, defaultExpiry)
+	accessToken, _ := ring.MakeJWT(defaultUserId, "user", defaultExpiry)
+
+	tests := []struct {
+		name          string
+		validate      func(string) (uuid.UUID, error)
+		tokenString   string
+		expectedError bool
+	}{
+		{
+			name:          "MFA token validates as MFA token",
+			validate:      ring.ValidateMFAToken,
+			tokenString:   mfaToken,
+			expectedError: false,
+		},
+		{
+			name:          "MFA token is not an access token",
+			validate:      ring.ValidateJWT,
+			tokenString:   mfaToken,
+			expectedError: true,
+		},
+		{
+			name:          "Access token is not an MFA token",
+			validate:      ring.ValidateMFAToken,
+			tokenString:   accessToken,
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actualUserId, err := tt.validate(tt.tokenString)
+			if (err != nil) != tt.expectedError {
+				t.Errorf("validate() error = %v, expectedError %v", err, tt.expectedError)
+			}
+			if !tt.expectedError && actualUserId != defaultUserId {
+				t.Errorf("validate() expects %v, got %v", defaultUserId, actualUserId)
+			}
+		})
+	}
+}
+
+func TestKeyRingIssuedAtPrecision(t *testing.T) {
+	ring, _ := NewSecretKeyRing("SGVsbG8sIFdvcmxkIQ==")
+
+	before := time.Now().Truncate(time.Microsecond)
+	token, err := ring.MakeJWT(uuid.New(), "user", time.Minute)
+	if err != nil {
+		t.Fatalf("MakeJWT() error = %v", err)
+	}
+	after := time.Now()
+
+	claims, err := ring.ParseJWT(token)
+	if err != nil {
+		t.Fatalf("ParseJWT() error = %v", err)
+	}
+
+	issuedAt := claims.IssuedAt.Time
+	if issuedAt.Before(before) || issuedAt.After(after) {
+		t.Errorf("ParseJWT() issued at %v, expected between %v and %v", issuedAt, before, after)
+	}
+}
+
+func TestLoadKeyRing(t *testing.T) {
+	_, ed25519Key, _ := ed25519.GenerateKey(rand.Reader)
+	weakRSAKey, _ := rsa.GenerateKey(rand.Reader, 1024)
+
+	tests := []struct {
+		name          string
+		setup         func(dir string)
+		signingKeyId  string
+		expectedError bool
+	}{
+		{
+			name:          "Empty directory",
+			setup:         func(dir string) {},
+			expectedError: true,
+		},
+		{
+			name: "Only public keys",
+			setup: func(dir string) {
+				writePublicKey(t, dir, "public", ed25519Key.Public())
+			},
+			expectedError: true,
+		},
+		{
+			name: "Missing configured signing key",
+			setup: func(dir string) {
+				writePrivateKey(t, dir, "present", ed25519Key)
+			},
+			signingKeyId:  "absent",
+			expectedError: true,
+		},
+		{
+			name: "RSA key too small",
+			setup: func(dir string) {
+				writePrivateKey(t, dir, "weak", weakRSAKey)
+			},
+			expectedError: true,
+		},
+		{
+			name: "Not a PEM file",
+			setup: func(dir string) {
+				os.WriteFile(filepath.Join(dir, "garbage.pem"), []byte("not a key"), 0600)
+			},
+			expectedError: true,
+		},
+		{
+			name: "Valid private key",
+			setup: func(dir string) {
+				writePrivateKey(t, dir, "valid", ed25519Key)
+			},
+			expectedError: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			tt.setup(dir)
+
+			_, err := LoadKeyRing(dir, tt.signingKeyId)
+			if (err != nil) != tt.expectedError {
+				t.Errorf("LoadKeyRing() error = %v, expectedError %v", err, tt.expectedError)
+			}
+		})
+	}
+}
+
+func TestKeyRingJWKS(t *testing.T) {
+	_, ed25519Key, _ := ed25519.GenerateKey(rand.Reader)
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	dir := t.TempDir()
+	writePrivateKey(t, dir, "b-ed25519", ed25519Key)
+	writePublicKey(t, dir, "a-rsa", rsaKey.Public())
+	ring, err := LoadKeyRing(dir, "")
+	if err != nil {
+		t.Fatalf("LoadKeyRing() error = %v", err)
+	}
+
+	jwks := ring.JWKS()
+	if len(jwks.Keys) != 2 {
+		t.Fatalf("JWKS() expects 2 keys, got %d", len(jwks.Keys))
+	}
+
+	rsaJWK := jwks.Keys[0]
+	if rsaJWK.KeyId != "a-rsa" || rsaJWK.KeyType != "RSA" || rsaJWK.Algorithm != "RS256" || rsaJWK.E != "AQAB" {
+		t.Errorf("JWKS() unexpected RSA key %+v", rsaJWK)
+	}
+
+	ed25519JWK := jwks.Keys[1]
+	if ed25519JWK.KeyId != "b-ed25519" || ed25519JWK.KeyType != "OKP" || ed25519JWK.Curve != "Ed25519" || ed25519JWK.Algorithm != "EdDSA" {
+		t.Errorf("JWKS() unexpected Ed25519 key %+v", ed25519JWK)
+	}
+
+	secretRing, _ := NewSecretKeyRing("SGVsbG8sIFdvcmxkIQ==")
+	if keys := secretRing.JWKS().Keys; len(keys) != 0 {
+		t.Errorf("JWKS() expects no keys for a shared secret, got %d", len(keys))
+	}
+}
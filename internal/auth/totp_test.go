@@ -0,0 +1,163 @@
+package auth
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA-1 test key from RFC 6238, "12345678901234567890",
+// encoded as base32.
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode(t *testing.T) {
+	tests := []struct {
+		name          string
+		time          int64
+		expectedValue string
+	}{
+		{name: "59", time: 59, expectedValue: "287082"},
+		{name: "1111111109", time: 1111111109, expectedValue: "081804"},
+		{name: "1111111111", time: 1111111111, expectedValue: "050471"},
+		{name: "1234567890", time: 1234567890, expectedValue: "005924"},
+		{name: "2000000000", time: 2000000000, expectedValue: "279037"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actualValue, err := TOTPCode(rfc6238Secret, TOTPStep(time.Unix(tt.time, 0)))
+			if err != nil {
+				t.Fatalf("TOTPCode() error = %v", err)
+			}
+			if actualValue != tt.expectedValue {
+				t.Errorf("TOTPCode() expects %v, got %v", tt.expectedValue, actualValue)
+			}
+		})
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	currentCode, _ := TOTPCode(rfc6238Secret, TOTPStep(now))
+	previousCode, _ := TOTPCode(rfc6238Secret, TOTPStep(now)-1)
+	staleCode, _ := TOTPCode(rfc6238Secret, TOTPStep(now)-2)
+
+	tests := []struct {
+		name         string
+		secret       string
+		code         string
+		wantErr      bool
+		expectedOk   bool
+		expectedStep int64
+	}{
+		{
+			name:         "Current code",
+			secret:       rfc6238Secret,
+			code:         currentCode,
+			expectedOk:   true,
+			expectedStep: TOTPStep(now),
+		},
+		{
+			name:         "Previous code within skew",
+			secret:       rfc6238Secret,
+			code:         previousCode,
+			expectedOk:   true,
+			expectedStep: TOTPStep(now) - 1,
+		},
+		{
+			name:       "Code outside skew",
+			secret:     rfc6238Secret,
+			code:       staleCode,
+			expectedOk: false,
+		},
+		{
+			name:       "Wrong length",
+			secret:     rfc6238Secret,
+			code:       "12345",
+			expectedOk: false,
+		},
+		{
+			name:    "Invalid secret",
+			secret:  "not base32!",
+			code:    "123456",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok, err := ValidateTOTP(tt.secret, tt.code, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateTOTP() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if ok != tt.expectedOk {
+				t.Errorf("ValidateTOTP() expects ok %v, got %v", tt.expectedOk, ok)
+			}
+			if ok && step != tt.expectedStep {
+				t.Errorf("ValidateTOTP() expects step %v, got %v", tt.expectedStep, step)
+			}
+		})
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri, err := url.Parse(TOTPURI(rfc6238Secret, "Chirpy", "user@example.com"))
+	if err != nil {
+		t.Fatalf("url.Parse() error = %v", err)
+	}
+
+	if uri.Scheme != "otpauth" || uri.Host != "totp" {
+		t.Errorf("TOTPURI() expects otpauth://totp, got %v://%v", uri.Scheme, uri.Host)
+	}
+	if uri.Path != "/Chirpy:user@example.com" {
+		t.Errorf("TOTPURI() expects label Chirpy:user@example.com, got %v", uri.Path)
+	}
+	if uri.Query().Get("secret") != rfc6238Secret {
+		t.Errorf("TOTPURI() expects secret %v, got %v", rfc6238Secret, uri.Query().Get("secret"))
+	}
+	if uri.Query().Get("issuer") != "Chirpy" {
+		t.Errorf("TOTPURI() expects issuer Chirpy, got %v", uri.Query().Get("issuer"))
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(10)
+	if err != nil {
+		t.Fatalf("GenerateRecoveryCodes() error = %v", err)
+	}
+	if len(codes) != 10 {
+		t.Fatalf("GenerateRecoveryCodes() expects 10 codes, got %v", len(codes))
+	}
+
+	seen := map[string]bool{}
+	for _, code := range codes {
+		if len(code) != 17 || code[8] != '-' {
+			t.Errorf("GenerateRecoveryCodes() returned malformed code %q", code)
+		}
+		if seen[code] {
+			t.Errorf("GenerateRecoveryCodes() returned duplicate code %q", code)
+		}
+		seen[code] = true
+	}
+
+	tests := []struct {
+		name          string
+		code          string
+		stored        string
+		expectedMatch bool
+	}{
+		{name: "Exact", code: codes[0], stored: codes[0], expectedMatch: true},
+		{name: "Upper case", code: "ABCD2EFG-HIJK3LMN", stored: "abcd2efg-hijk3lmn", expectedMatch: true},
+		{name: "Without separator", code: "abcd2efghijk3lmn", stored: "abcd2efg-hijk3lmn", expectedMatch: true},
+		{name: "Different code", code: "abcd2efg-hijk3lmm", stored: "abcd2efg-hijk3lmn", expectedMatch: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match := HashRecoveryCode(tt.code) == HashRecoveryCode(tt.stored)
+			if match != tt.expectedMatch {
+				t.Errorf("HashRecoveryCode() match expects %v, got %v", tt.expectedMatch, match)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	WebhookTimestampHeader = "X-Polka-Timestamp"
+	WebhookSignatureHeader = "X-Polka-Signature"
+)
+
+var (
+	ErrWebhookSignatureMissing = errors.New("webhook signature or timestamp is missing")
+	ErrWebhookTimestampStale   = errors.New("webhook timestamp is outside the allowed tolerance")
+	ErrWebhookSignatureInvalid = errors.New("webhook signature does not match")
+)
+
+// SignWebhook returns the hex encoded HMAC-SHA256 of the timestamp, a dot and
+// the raw body. Covering the timestamp stops an old payload from being
+// replayed with a fresh timestamp.
+func SignWebhook(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhook checks the signature headers of a webhook request against
+// each of the secrets, so a new secret can be added before the old one is
+// removed. Requests whose timestamp is further than tolerance from now are
+// rejected to limit replays.
+func VerifyWebhook(headers http.Header, body []byte, secrets []string, now time.Time, tolerance time.Duration) error {
+	timestamp := headers.Get(WebhookTimestampHeader)
+	signature := headers.Get(WebhookSignatureHeader)
+	if len(timestamp) == 0 || len(signature) == 0 {
+		return ErrWebhookSignatureMissing
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrWebhookSignatureMissing
+	}
+
+	age := now.Sub(time.Unix(seconds, 0))
+	if age > tolerance || age < -tolerance {
+		return ErrWebhookTimestampStale
+	}
+
+	actual, err := hex.DecodeString(signature)
+	if err != nil {
+		return ErrWebhookSignatureInvalid
+	}
+
+	for _, secret := range secrets {
+		if len(secret) == 0 {
+			continue
+		}
+
+		expected, _ := hex.DecodeString(SignWebhook(secret, timestamp, body))
+		if hmac.Equal(expected, actual) {
+			return nil
+		}
+	}
+
+	return ErrWebhookSignatureInvalid
+}
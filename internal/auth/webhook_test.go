@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestVerifyWebhook(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	tolerance := 5 * time.Minute
+	body := []byte(`{"event":"user.upgraded","data":{"user_id":"b92b8014-6b95-42df-99d6-1e78551bb6ea"}}`)
+	currentSecret := "current-secret"
+	previousSecret := "previous-secret"
+	secrets := []string{currentSecret, previousSecret}
+
+	makeHeaders := func(secret string, timestamp time.Time, body []byte) http.Header {
+		headers := http.Header{}
+		value := strconv.FormatInt(timestamp.Unix(), 10)
+		headers.Set(WebhookTimestampHeader, value)
+		headers.Set(WebhookSignatureHeader, SignWebhook(secret, value, body))
+		return headers
+	}
+
+	tests := []struct {
+		name          string
+		headers       http.Header
+		body          []byte
+		secrets       []string
+		expectedError error
+	}{
+		{
+			name:          "Signed with current secret",
+			headers:       makeHeaders(currentSecret, now, body),
+			body:          body,
+			secrets:       secrets,
+			expectedError: nil,
+		},
+		{
+			name:          "Signed with previous secret",
+			headers:       makeHeaders(previousSecret, now, body),
+			body:          body,
+			secrets:       secrets,
+			expectedError: nil,
+		},
+		{
+			name:          "Signed with unknown secret",
+			headers:       makeHeaders("other-secret", now, body),
+			body:          body,
+			secrets:       secrets,
+			expectedError: ErrWebhookSignatureInvalid,
+		},
+		{
+			name:          "Body changed after signing",
+			headers:       makeHeaders(currentSecret, now, body),
+			body:          []byte(`{"event":"user.upgraded","data":{}}`),
+			secrets:       secrets,
+			expectedError: ErrWebhookSignatureInvalid,
+		},
+		{
+			name:          "Timestamp within tolerance",
+			headers:       makeHeaders(currentSecret, now.Add(-4*time.Minute), body),
+			body:          body,
+			secrets:       secrets,
+			expectedError: nil,
+		},
+		{
+			name:          "Timestamp too old",
+			headers:       makeHeaders(currentSecret, now.Add(-6*time.Minute), body),
+			body:          body,
+			secrets:       secrets,
+			expectedError: ErrWebhookTimestampStale,
+		},
+		{
+			name:          "Timestamp too far ahead",
+			headers:       makeHeaders(currentSecret, now.Add(6*time.Minute), body),
+			body:          body,
+			secrets:       secrets,
+			expectedError: ErrWebhookTimestampStale,
+		},
+		{
+			name:          "Missing headers",
+			headers:       http.Header{},
+			body:          body,
+			secrets:       secrets,
+			expectedError: ErrWebhookSignatureMissing,
+		},
+		{
+			name:          "Empty secrets never match",
+			headers:       makeHeaders("", now, body),
+			body:          body,
+			secrets:       []string{"", ""},
+			expectedError: ErrWebhookSignatureInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyWebhook(tt.headers, tt.body, tt.secrets, now, tolerance)
+			if !errors.Is(err, tt.expectedError) {
+				t.Errorf("VerifyWebhook() error = %v, expectedError %v", err, tt.expectedError)
+			}
+		})
+	}
+}
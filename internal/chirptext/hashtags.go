@@ -0,0 +1,34 @@
+package chirptext
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var hashtagRegexp = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_&/#])#([\p{L}\p{N}_]+)`)
+
+// ExtractHashtags returns the distinct hashtags in body, lowercased and
+// without the leading #, in order of first appearance. Tags made up only of
+// digits and underscores, such as #1, are ignored.
+func ExtractHashtags(body string) []string {
+	tags := []string{}
+	seen := map[string]bool{}
+
+	for _, match := range hashtagRegexp.FindAllStringSubmatch(body, -1) {
+		tag := NormalizeHashtag(match[1])
+		if !strings.ContainsFunc(tag, unicode.IsLetter) || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+
+	return tags
+}
+
+// NormalizeHashtag converts user supplied tag text, with or without the
+// leading #, into the form stored by ExtractHashtags.
+func NormalizeHashtag(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(tag, "#"))
+}
@@ -0,0 +1,64 @@
+package chirptext
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestExtractHashtags(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		expectedValue []string
+	}{
+		{
+			name:          "No hashtags",
+			body:          "Just a regular chirp",
+			expectedValue: []string{},
+		},
+		{
+			name:          "Single hashtag",
+			body:          "Learning #golang today",
+			expectedValue: []string{"golang"},
+		},
+		{
+			name:          "Hashtag at start and end",
+			body:          "#bootdev is fun #Go",
+			expectedValue: []string{"bootdev", "go"},
+		},
+		{
+			name:          "Duplicates are case insensitive",
+			body:          "#Chirpy #chirpy #CHIRPY",
+			expectedValue: []string{"chirpy"},
+		},
+		{
+			name:          "Trailing punctuation is excluded",
+			body:          "Ship it (#release), then #party!",
+			expectedValue: []string{"release", "party"},
+		},
+		{
+			name:          "Numeric tags are ignored",
+			body:          "We are #1 and #2024_goals",
+			expectedValue: []string{"2024_goals"},
+		},
+		{
+			name:          "Anchors and entities are ignored",
+			body:          "See page#intro or &#39; or ##double",
+			expectedValue: []string{},
+		},
+		{
+			name:          "Unicode letters",
+			body:          "Café time #café",
+			expectedValue: []string{"café"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actualValue := ExtractHashtags(tt.body)
+			if !slices.Equal(actualValue, tt.expectedValue) {
+				t.Errorf("ExtractHashtags() expects %v, got %v", tt.expectedValue, actualValue)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package chirptext
+
+import (
+	"regexp"
+	"strings"
+)
+
+const (
+	minHandleLength = 3
+	maxHandleLength = 30
+)
+
+var handleRegexp = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+var mentionRegexp = regexp.MustCompile(`(?:^|[^A-Za-z0-9_@])@([A-Za-z0-9_]+)`)
+
+// IsValidHandle reports whether handle can be used as a user's handle, which is
+// what makes it reachable through an @mention.
+func IsValidHandle(handle string) bool {
+	return len(handle) >= minHandleLength &&
+		len(handle) <= maxHandleLength &&
+		handleRegexp.MatchString(handle)
+}
+
+// ExtractMentions returns the distinct @handles in body, lowercased and
+// without the leading @, in order of first appearance. Email addresses and
+// text that could never be a valid handle are ignored.
+func ExtractMentions(body string) []string {
+	handles := []string{}
+	seen := map[string]bool{}
+
+	for _, match := range mentionRegexp.FindAllStringSubmatch(body, -1) {
+		handle := strings.ToLower(match[1])
+		if !IsValidHandle(handle) || seen[handle] {
+			continue
+		}
+		seen[handle] = true
+		handles = append(handles, handle)
+	}
+
+	return handles
+}
@@ -0,0 +1,97 @@
+package chirptext
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestIsValidHandle(t *testing.T) {
+	tests := []struct {
+		name          string
+		handle        string
+		expectedValue bool
+	}{
+		{
+			name:          "Letters digits and underscores",
+			handle:        "Chirpy_Fan42",
+			expectedValue: true,
+		},
+		{
+			name:          "Too short",
+			handle:        "ab",
+			expectedValue: false,
+		},
+		{
+			name:          "Too long",
+			handle:        "abcdefghijklmnopqrstuvwxyz12345",
+			expectedValue: false,
+		},
+		{
+			name:          "Contains punctuation",
+			handle:        "chirpy.fan",
+			expectedValue: false,
+		},
+		{
+			name:          "Contains non-ASCII letters",
+			handle:        "café",
+			expectedValue: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actualValue := IsValidHandle(tt.handle)
+			if actualValue != tt.expectedValue {
+				t.Errorf("IsValidHandle() expects %v, got %v", tt.expectedValue, actualValue)
+			}
+		})
+	}
+}
+
+func TestExtractMentions(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		expectedValue []string
+	}{
+		{
+			name:          "No mentions",
+			body:          "Just a regular chirp",
+			expectedValue: []string{},
+		},
+		{
+			name:          "Single mention",
+			body:          "Thanks @alice!",
+			expectedValue: []string{"alice"},
+		},
+		{
+			name:          "Mention at start",
+			body:          "@bob_99 check this out",
+			expectedValue: []string{"bob_99"},
+		},
+		{
+			name:          "Duplicates are case insensitive",
+			body:          "@Alice @alice @ALICE and @carol",
+			expectedValue: []string{"alice", "carol"},
+		},
+		{
+			name:          "Email addresses are ignored",
+			body:          "Mail me at alice@example.com",
+			expectedValue: []string{},
+		},
+		{
+			name:          "Invalid handles are ignored",
+			body:          "@al @@double",
+			expectedValue: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actualValue := ExtractMentions(tt.body)
+			if !slices.Equal(actualValue, tt.expectedValue) {
+				t.Errorf("ExtractMentions() expects %v, got %v", tt.expectedValue, actualValue)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: banned_words.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const createBannedWord = `-- name: CreateBannedWord :one
+INSERT INTO banned_words (
+    word,
+    match_mode
+) VALUES (
+    $1,
+    $2
+)
+RETURNING id, created_at, word, match_mode
+`
+
+type CreateBannedWordParams struct {
+	Word      string
+	MatchMode BannedWordMatchMode
+}
+
+func (q *Queries) CreateBannedWord(ctx context.Context, arg CreateBannedWordParams) (BannedWord, error) {
+	row := q.db.QueryRowContext(ctx, createBannedWord, arg.Word, arg.MatchMode)
+	var i BannedWord
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.Word,
+		&i.MatchMode,
+	)
+	return i, err
+}
+
+const deleteBannedWord = `-- name: DeleteBannedWord :execrows
+DELETE FROM banned_words
+WHERE id = $1
+`
+
+func (q *Queries) DeleteBannedWord(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteBannedWord, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getBannedWords = `-- name: GetBannedWords :many
+SELECT id, created_at, word, match_mode
+FROM banned_words
+ORDER BY banned_words.created_at, banned_words.id
+`
+
+func (q *Queries) GetBannedWords(ctx context.Context) ([]BannedWord, error) {
+	rows, err := q.db.QueryContext(ctx, getBannedWords)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []BannedWord
+	for rows.Next() {
+		var i BannedWord
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.Word,
+			&i.MatchMode,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
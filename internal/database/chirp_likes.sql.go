@@ -0,0 +1,215 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: chirp_likes.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const getLikeCounts = `-- name: GetLikeCounts :many
+SELECT chirp_likes.chirp_id, count(*) AS like_count
+FROM chirp_likes
+WHERE chirp_likes.chirp_id = ANY($1::uuid[])
+GROUP BY chirp_likes.chirp_id
+`
+
+type GetLikeCountsRow struct {
+	ChirpID   uuid.UUID
+	LikeCount int64
+}
+
+func (q *Queries) GetLikeCounts(ctx context.Context, chirpIds []uuid.UUID) ([]GetLikeCountsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getLikeCounts, pq.Array(chirpIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetLikeCountsRow
+	for rows.Next() {
+		var i GetLikeCountsRow
+		if err := rows.Scan(&i.ChirpID, &i.LikeCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLikedChirpIds = `-- name: GetLikedChirpIds :many
+SELECT chirp_likes.chirp_id
+FROM chirp_likes
+WHERE
+    chirp_likes.user_id = $1 AND
+    chirp_likes.chirp_id = ANY($2::uuid[])
+`
+
+type GetLikedChirpIdsParams struct {
+	UserID   uuid.UUID
+	ChirpIds []uuid.UUID
+}
+
+func (q *Queries) GetLikedChirpIds(ctx context.Context, arg GetLikedChirpIdsParams) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, getLikedChirpIds, arg.UserID, pq.Array(arg.ChirpIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var chirp_id uuid.UUID
+		if err := rows.Scan(&chirp_id); err != nil {
+			return nil, err
+		}
+		items = append(items, chirp_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const likeChirp = `-- name: LikeChirp :exec
+INSERT INTO chirp_likes (
+    chirp_id,
+    user_id
+) VALUES (
+    $1,
+    $2
+)
+ON CONFLICT DO NOTHING
+`
+
+type LikeChirpParams struct {
+	ChirpID uuid.UUID
+	UserID  uuid.UUID
+}
+
+func (q *Queries) LikeChirp(ctx context.Context, arg LikeChirpParams) error {
+	_, err := q.db.ExecContext(ctx, likeChirp, arg.ChirpID, arg.UserID)
+	return err
+}
+
+const listChirpLikesByUser = `-- name: ListChirpLikesByUser :many
+SELECT chirp_id, user_id, created_at
+FROM chirp_likes
+WHERE user_id = $1
+ORDER BY created_at, chirp_id
+`
+
+func (q *Queries) ListChirpLikesByUser(ctx context.Context, userID uuid.UUID) ([]ChirpLike, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpLikesByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ChirpLike
+	for rows.Next() {
+		var i ChirpLike
+		if err := rows.Scan(&i.ChirpID, &i.UserID, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listLikedChirps = `-- name: ListLikedChirps :many
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.in_reply_to, chirp_likes.created_at AS liked_at
+FROM chirp_likes
+INNER JOIN chirps
+ON chirps.id = chirp_likes.chirp_id
+WHERE
+    chirp_likes.user_id = $1 AND
+    ($2::timestamptz IS NULL OR
+        (chirp_likes.created_at, chirp_likes.chirp_id) < ($2, $3::uuid))
+ORDER BY chirp_likes.created_at DESC, chirp_likes.chirp_id DESC
+LIMIT $4
+`
+
+type ListLikedChirpsParams struct {
+	UserID        uuid.UUID
+	BeforeLikedAt sql.NullTime
+	BeforeID      uuid.NullUUID
+	Limit         int32
+}
+
+type ListLikedChirpsRow struct {
+	Chirp   Chirp
+	LikedAt time.Time
+}
+
+func (q *Queries) ListLikedChirps(ctx context.Context, arg ListLikedChirpsParams) ([]ListLikedChirpsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listLikedChirps,
+		arg.UserID,
+		arg.BeforeLikedAt,
+		arg.BeforeID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListLikedChirpsRow
+	for rows.Next() {
+		var i ListLikedChirpsRow
+		if err := rows.Scan(
+			&i.Chirp.ID,
+			&i.Chirp.CreatedAt,
+			&i.Chirp.UpdatedAt,
+			&i.Chirp.Body,
+			&i.Chirp.UserID,
+			&i.Chirp.InReplyTo,
+			&i.LikedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const unlikeChirp = `-- name: UnlikeChirp :exec
+DELETE FROM chirp_likes
+WHERE
+    chirp_likes.chirp_id = $1 AND
+    chirp_likes.user_id = $2
+`
+
+type UnlikeChirpParams struct {
+	ChirpID uuid.UUID
+	UserID  uuid.UUID
+}
+
+func (q *Queries) UnlikeChirp(ctx context.Context, arg UnlikeChirpParams) error {
+	_, err := q.db.ExecContext(ctx, unlikeChirp, arg.ChirpID, arg.UserID)
+	return err
+}
@@ -0,0 +1,135 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: chirp_mentions.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const clearChirpMentions = `-- name: ClearChirpMentions :exec
+DELETE FROM chirp_mentions
+WHERE chirp_mentions.chirp_id = $1
+`
+
+func (q *Queries) ClearChirpMentions(ctx context.Context, chirpID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, clearChirpMentions, chirpID)
+	return err
+}
+
+const getChirpMentions = `-- name: GetChirpMentions :many
+SELECT chirp_mentions.chirp_id, users.id AS user_id, users.username
+FROM chirp_mentions
+INNER JOIN users
+ON users.id = chirp_mentions.user_id
+WHERE chirp_mentions.chirp_id = ANY($1::uuid[])
+ORDER BY chirp_mentions.chirp_id, lower(users.username)
+`
+
+type GetChirpMentionsRow struct {
+	ChirpID  uuid.UUID
+	UserID   uuid.UUID
+	Username sql.NullString
+}
+
+func (q *Queries) GetChirpMentions(ctx context.Context, chirpIds []uuid.UUID) ([]GetChirpMentionsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpMentions, pq.Array(chirpIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetChirpMentionsRow
+	for rows.Next() {
+		var i GetChirpMentionsRow
+		if err := rows.Scan(&i.ChirpID, &i.UserID, &i.Username); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMentioningChirps = `-- name: ListMentioningChirps :many
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.in_reply_to
+FROM chirps
+INNER JOIN chirp_mentions
+ON chirp_mentions.chirp_id = chirps.id
+WHERE
+    chirp_mentions.user_id = $1 AND
+    ($2::timestamptz IS NULL OR
+        (chirps.created_at, chirps.id) < ($2, $3::uuid))
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT $4
+`
+
+type ListMentioningChirpsParams struct {
+	UserID          uuid.UUID
+	BeforeCreatedAt sql.NullTime
+	BeforeID        uuid.NullUUID
+	Limit           int32
+}
+
+func (q *Queries) ListMentioningChirps(ctx context.Context, arg ListMentioningChirpsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listMentioningChirps,
+		arg.UserID,
+		arg.BeforeCreatedAt,
+		arg.BeforeID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.InReplyTo,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const mentionUsers = `-- name: MentionUsers :exec
+INSERT INTO chirp_mentions (chirp_id, user_id)
+SELECT $1::uuid, users.id
+FROM users
+WHERE lower(users.username) = ANY($2::text[])
+ON CONFLICT DO NOTHING
+`
+
+type MentionUsersParams struct {
+	ChirpID uuid.UUID
+	Handles []string
+}
+
+func (q *Queries) MentionUsers(ctx context.Context, arg MentionUsersParams) error {
+	_, err := q.db.ExecContext(ctx, mentionUsers, arg.ChirpID, pq.Array(arg.Handles))
+	return err
+}
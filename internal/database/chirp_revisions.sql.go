@@ -0,0 +1,112 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: chirp_revisions.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const createChirpRevision = `-- name: CreateChirpRevision :one
+INSERT INTO chirp_revisions (
+    chirp_id,
+    body
+) VALUES (
+    $1,
+    $2
+)
+RETURNING id, created_at, chirp_id, body
+`
+
+type CreateChirpRevisionParams struct {
+	ChirpID uuid.UUID
+	Body    string
+}
+
+func (q *Queries) CreateChirpRevision(ctx context.Context, arg CreateChirpRevisionParams) (ChirpRevision, error) {
+	row := q.db.QueryRowContext(ctx, createChirpRevision, arg.ChirpID, arg.Body)
+	var i ChirpRevision
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.ChirpID,
+		&i.Body,
+	)
+	return i, err
+}
+
+const getChirpRevisions = `-- name: GetChirpRevisions :many
+SELECT id, created_at, chirp_id, body
+FROM chirp_revisions
+WHERE chirp_revisions.chirp_id = $1
+ORDER BY chirp_revisions.created_at, chirp_revisions.id
+`
+
+func (q *Queries) GetChirpRevisions(ctx context.Context, chirpID uuid.UUID) ([]ChirpRevision, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpRevisions, chirpID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ChirpRevision
+	for rows.Next() {
+		var i ChirpRevision
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.ChirpID,
+			&i.Body,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChirpRevisionsByUser = `-- name: ListChirpRevisionsByUser :many
+SELECT chirp_revisions.id, chirp_revisions.created_at, chirp_revisions.chirp_id, chirp_revisions.body
+FROM chirp_revisions
+INNER JOIN chirps
+ON chirp_revisions.chirp_id = chirps.id
+WHERE chirps.user_id = $1
+ORDER BY chirp_revisions.created_at, chirp_revisions.id
+`
+
+func (q *Queries) ListChirpRevisionsByUser(ctx context.Context, userID uuid.UUID) ([]ChirpRevision, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpRevisionsByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ChirpRevision
+	for rows.Next() {
+		var i ChirpRevision
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.ChirpID,
+			&i.Body,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
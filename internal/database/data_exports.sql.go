@@ -0,0 +1,162 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: data_exports.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const completeDataExport = `-- name: CompleteDataExport :exec
+UPDATE data_exports
+SET
+    status = 'ready',
+    archive = $2,
+    completed_at = now(),
+    expires_at = $3
+WHERE
+    id = $1 AND
+    status = 'pending'
+`
+
+type CompleteDataExportParams struct {
+	ID        uuid.UUID
+	Archive   []byte
+	ExpiresAt sql.NullTime
+}
+
+func (q *Queries) CompleteDataExport(ctx context.Context, arg CompleteDataExportParams) error {
+	_, err := q.db.ExecContext(ctx, completeDataExport, arg.ID, arg.Archive, arg.ExpiresAt)
+	return err
+}
+
+const createDataExport = `-- name: CreateDataExport :one
+INSERT INTO data_exports (user_id)
+VALUES ($1)
+RETURNING id, created_at, user_id, status, archive, completed_at, expires_at
+`
+
+func (q *Queries) CreateDataExport(ctx context.Context, userID uuid.UUID) (DataExport, error) {
+	row := q.db.QueryRowContext(ctx, createDataExport, userID)
+	var i DataExport
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UserID,
+		&i.Status,
+		&i.Archive,
+		&i.CompletedAt,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const deleteExpiredDataExports = `-- name: DeleteExpiredDataExports :execrows
+DELETE FROM data_exports
+WHERE expires_at <= now()
+`
+
+func (q *Queries) DeleteExpiredDataExports(ctx context.Context) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteExpiredDataExports)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const failDataExport = `-- name: FailDataExport :exec
+UPDATE data_exports
+SET status = 'failed', completed_at = now(), expires_at = $2
+WHERE id = $1
+`
+
+type FailDataExportParams struct {
+	ID        uuid.UUID
+	ExpiresAt sql.NullTime
+}
+
+func (q *Queries) FailDataExport(ctx context.Context, arg FailDataExportParams) error {
+	_, err := q.db.ExecContext(ctx, failDataExport, arg.ID, arg.ExpiresAt)
+	return err
+}
+
+const failStaleDataExports = `-- name: FailStaleDataExports :execrows
+UPDATE data_exports
+SET status = 'failed', completed_at = now(), expires_at = $1
+WHERE
+    status = 'pending' AND
+    created_at < $2
+`
+
+type FailStaleDataExportsParams struct {
+	ExpiresAt   sql.NullTime
+	StaleBefore time.Time
+}
+
+// Exports still pending after their build timeout were abandoned, for
+// example by a restart, and would otherwise block new exports for the user.
+func (q *Queries) FailStaleDataExports(ctx context.Context, arg FailStaleDataExportsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, failStaleDataExports, arg.ExpiresAt, arg.StaleBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getDataExport = `-- name: GetDataExport :one
+SELECT id, created_at, user_id, status, archive, completed_at, expires_at
+FROM data_exports
+WHERE
+    id = $1 AND
+    user_id = $2 AND
+    (expires_at IS NULL OR expires_at > now())
+`
+
+type GetDataExportParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) GetDataExport(ctx context.Context, arg GetDataExportParams) (DataExport, error) {
+	row := q.db.QueryRowContext(ctx, getDataExport, arg.ID, arg.UserID)
+	var i DataExport
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UserID,
+		&i.Status,
+		&i.Archive,
+		&i.CompletedAt,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const getPendingDataExport = `-- name: GetPendingDataExport :one
+SELECT id, created_at, user_id, status, archive, completed_at, expires_at
+FROM data_exports
+WHERE
+    user_id = $1 AND
+    status = 'pending'
+`
+
+func (q *Queries) GetPendingDataExport(ctx context.Context, userID uuid.UUID) (DataExport, error) {
+	row := q.db.QueryRowContext(ctx, getPendingDataExport, userID)
+	var i DataExport
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UserID,
+		&i.Status,
+		&i.Archive,
+		&i.CompletedAt,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
@@ -0,0 +1,79 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: email_verification_tokens.sql
+
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const createEmailVerificationToken = `-- name: CreateEmailVerificationToken :exec
+INSERT INTO email_verification_tokens (
+    token_hash,
+    user_id,
+    email,
+    expires_at
+) VALUES (
+    $1,
+    $2,
+    $3,
+    $4
+)
+`
+
+type CreateEmailVerificationTokenParams struct {
+	TokenHash string
+	UserID    uuid.UUID
+	Email     string
+	ExpiresAt time.Time
+}
+
+func (q *Queries) CreateEmailVerificationToken(ctx context.Context, arg CreateEmailVerificationTokenParams) error {
+	_, err := q.db.ExecContext(ctx, createEmailVerificationToken,
+		arg.TokenHash,
+		arg.UserID,
+		arg.Email,
+		arg.ExpiresAt,
+	)
+	return err
+}
+
+const expireEmailVerificationTokens = `-- name: ExpireEmailVerificationTokens :exec
+UPDATE email_verification_tokens
+SET used_at = now()
+WHERE
+    user_id = $1 AND
+    used_at IS NULL
+`
+
+func (q *Queries) ExpireEmailVerificationTokens(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, expireEmailVerificationTokens, userID)
+	return err
+}
+
+const useEmailVerificationToken = `-- name: UseEmailVerificationToken :one
+UPDATE email_verification_tokens
+SET used_at = now()
+WHERE
+    token_hash = $1 AND
+    expires_at > now() AND
+    used_at IS NULL
+RETURNING user_id, email
+`
+
+type UseEmailVerificationTokenRow struct {
+	UserID uuid.UUID
+	Email  string
+}
+
+func (q *Queries) UseEmailVerificationToken(ctx context.Context, tokenHash string) (UseEmailVerificationTokenRow, error) {
+	row := q.db.QueryRowContext(ctx, useEmailVerificationToken, tokenHash)
+	var i UseEmailVerificationTokenRow
+	err := row.Scan(&i.UserID, &i.Email)
+	return i, err
+}
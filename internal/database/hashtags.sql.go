@@ -0,0 +1,189 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: hashtags.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const getChirpHashtags = `-- name: GetChirpHashtags :many
+SELECT chirp_hashtags.chirp_id, hashtags.tag
+FROM chirp_hashtags
+INNER JOIN hashtags
+ON hashtags.id = chirp_hashtags.hashtag_id
+WHERE chirp_hashtags.chirp_id = ANY($1::uuid[])
+ORDER BY chirp_hashtags.chirp_id, hashtags.tag
+`
+
+type GetChirpHashtagsRow struct {
+	ChirpID uuid.UUID
+	Tag     string
+}
+
+func (q *Queries) GetChirpHashtags(ctx context.Context, chirpIds []uuid.UUID) ([]GetChirpHashtagsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpHashtags, pq.Array(chirpIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetChirpHashtagsRow
+	for rows.Next() {
+		var i GetChirpHashtagsRow
+		if err := rows.Scan(&i.ChirpID, &i.Tag); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTrendingHashtags = `-- name: GetTrendingHashtags :many
+SELECT hashtags.tag, count(*) AS chirp_count
+FROM chirp_hashtags
+INNER JOIN hashtags
+ON hashtags.id = chirp_hashtags.hashtag_id
+INNER JOIN chirps
+ON chirps.id = chirp_hashtags.chirp_id
+WHERE chirps.created_at >= $1
+GROUP BY hashtags.tag
+ORDER BY chirp_count DESC, hashtags.tag
+LIMIT $2
+`
+
+type GetTrendingHashtagsParams struct {
+	Since time.Time
+	Limit int32
+}
+
+type GetTrendingHashtagsRow struct {
+	Tag        string
+	ChirpCount int64
+}
+
+func (q *Queries) GetTrendingHashtags(ctx context.Context, arg GetTrendingHashtagsParams) ([]GetTrendingHashtagsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getTrendingHashtags, arg.Since, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTrendingHashtagsRow
+	for rows.Next() {
+		var i GetTrendingHashtagsRow
+		if err := rows.Scan(&i.Tag, &i.ChirpCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listHashtagChirps = `-- name: ListHashtagChirps :many
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.in_reply_to
+FROM chirps
+INNER JOIN chirp_hashtags
+ON chirp_hashtags.chirp_id = chirps.id
+INNER JOIN hashtags
+ON hashtags.id = chirp_hashtags.hashtag_id
+WHERE
+    hashtags.tag = $1 AND
+    ($2::timestamptz IS NULL OR
+        (chirps.created_at, chirps.id) < ($2, $3::uuid))
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT $4
+`
+
+type ListHashtagChirpsParams struct {
+	Tag             string
+	BeforeCreatedAt sql.NullTime
+	BeforeID        uuid.NullUUID
+	Limit           int32
+}
+
+func (q *Queries) ListHashtagChirps(ctx context.Context, arg ListHashtagChirpsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listHashtagChirps,
+		arg.Tag,
+		arg.BeforeCreatedAt,
+		arg.BeforeID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.InReplyTo,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const tagChirp = `-- name: TagChirp :exec
+WITH tags AS (
+    INSERT INTO hashtags (tag)
+    SELECT unnest($2::text[])
+    ON CONFLICT (tag) DO UPDATE
+    SET tag = EXCLUDED.tag
+    RETURNING id
+)
+INSERT INTO chirp_hashtags (chirp_id, hashtag_id)
+SELECT $1::uuid, tags.id
+FROM tags
+ON CONFLICT DO NOTHING
+`
+
+type TagChirpParams struct {
+	ChirpID uuid.UUID
+	Tags    []string
+}
+
+func (q *Queries) TagChirp(ctx context.Context, arg TagChirpParams) error {
+	_, err := q.db.ExecContext(ctx, tagChirp, arg.ChirpID, pq.Array(arg.Tags))
+	return err
+}
+
+const untagChirp = `-- name: UntagChirp :exec
+DELETE FROM chirp_hashtags
+WHERE chirp_hashtags.chirp_id = $1
+`
+
+func (q *Queries) UntagChirp(ctx context.Context, chirpID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, untagChirp, chirpID)
+	return err
+}
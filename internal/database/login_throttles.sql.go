@@ -0,0 +1,129 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: login_throttles.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const clearLoginThrottle = `-- name: ClearLoginThrottle :execrows
+DELETE FROM login_throttles
+WHERE
+    scope = $1 AND
+    subject = $2
+`
+
+type ClearLoginThrottleParams struct {
+	Scope   LoginThrottleScope
+	Subject string
+}
+
+func (q *Queries) ClearLoginThrottle(ctx context.Context, arg ClearLoginThrottleParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, clearLoginThrottle, arg.Scope, arg.Subject)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteStaleLoginThrottles = `-- name: DeleteStaleLoginThrottles :execrows
+DELETE FROM login_throttles
+WHERE
+    scope = $1 AND
+    last_failed_at < $2 AND
+    (locked_until IS NULL OR locked_until < now())
+`
+
+type DeleteStaleLoginThrottlesParams struct {
+	Scope       LoginThrottleScope
+	ResetBefore time.Time
+}
+
+// Rows whose failures are all older than the policy's window and that are
+// not locked out count for nothing, so they can go.
+func (q *Queries) DeleteStaleLoginThrottles(ctx context.Context, arg DeleteStaleLoginThrottlesParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteStaleLoginThrottles, arg.Scope, arg.ResetBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getLoginLockedUntil = `-- name: GetLoginLockedUntil :one
+SELECT locked_until
+FROM login_throttles
+WHERE
+    scope = $1 AND
+    subject = $2
+`
+
+type GetLoginLockedUntilParams struct {
+	Scope   LoginThrottleScope
+	Subject string
+}
+
+func (q *Queries) GetLoginLockedUntil(ctx context.Context, arg GetLoginLockedUntilParams) (sql.NullTime, error) {
+	row := q.db.QueryRowContext(ctx, getLoginLockedUntil, arg.Scope, arg.Subject)
+	var locked_until sql.NullTime
+	err := row.Scan(&locked_until)
+	return locked_until, err
+}
+
+const lockLogin = `-- name: LockLogin :exec
+UPDATE login_throttles
+SET locked_until = $3
+WHERE
+    scope = $1 AND
+    subject = $2
+`
+
+type LockLoginParams struct {
+	Scope       LoginThrottleScope
+	Subject     string
+	LockedUntil sql.NullTime
+}
+
+func (q *Queries) LockLogin(ctx context.Context, arg LockLoginParams) error {
+	_, err := q.db.ExecContext(ctx, lockLogin, arg.Scope, arg.Subject, arg.LockedUntil)
+	return err
+}
+
+const recordLoginFailure = `-- name: RecordLoginFailure :one
+INSERT INTO login_throttles (
+    scope,
+    subject,
+    failed_attempts,
+    last_failed_at
+) VALUES (
+    $1,
+    $2,
+    1,
+    now()
+)
+ON CONFLICT (scope, subject) DO UPDATE
+SET
+    failed_attempts = CASE
+        WHEN login_throttles.last_failed_at < $3 THEN 1
+        ELSE login_throttles.failed_attempts + 1
+    END,
+    last_failed_at = now()
+RETURNING failed_attempts
+`
+
+type RecordLoginFailureParams struct {
+	Scope       LoginThrottleScope
+	Subject     string
+	ResetBefore time.Time
+}
+
+func (q *Queries) RecordLoginFailure(ctx context.Context, arg RecordLoginFailureParams) (int32, error) {
+	row := q.db.QueryRowContext(ctx, recordLoginFailure, arg.Scope, arg.Subject, arg.ResetBefore)
+	var failed_attempts int32
+	err := row.Scan(&failed_attempts)
+	return failed_attempts, err
+}
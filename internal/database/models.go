@@ -6,33 +6,419 @@ package database
 
 import (
 	"database/sql"
+	"database/sql/driver"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+type BannedWordMatchMode string
+
+const (
+	BannedWordMatchModeWholeWord BannedWordMatchMode = "whole_word"
+	BannedWordMatchModeSubstring BannedWordMatchMode = "substring"
+	BannedWordMatchModeRegex     BannedWordMatchMode = "regex"
+)
+
+func (e *BannedWordMatchMode) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = BannedWordMatchMode(s)
+	case string:
+		*e = BannedWordMatchMode(s)
+	default:
+		return fmt.Errorf("unsupported scan type for BannedWordMatchMode: %T", src)
+	}
+	return nil
+}
+
+type NullBannedWordMatchMode struct {
+	BannedWordMatchMode BannedWordMatchMode
+	Valid               bool // Valid is true if BannedWordMatchMode is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullBannedWordMatchMode) Scan(value interface{}) error {
+	if value == nil {
+		ns.BannedWordMatchMode, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.BannedWordMatchMode.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullBannedWordMatchMode) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.BannedWordMatchMode), nil
+}
+
+type DataExportStatus string
+
+const (
+	DataExportStatusPending DataExportStatus = "pending"
+	DataExportStatusReady   DataExportStatus = "ready"
+	DataExportStatusFailed  DataExportStatus = "failed"
+)
+
+func (e *DataExportStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = DataExportStatus(s)
+	case string:
+		*e = DataExportStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for DataExportStatus: %T", src)
+	}
+	return nil
+}
+
+type NullDataExportStatus struct {
+	DataExportStatus DataExportStatus
+	Valid            bool // Valid is true if DataExportStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullDataExportStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.DataExportStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.DataExportStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullDataExportStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.DataExportStatus), nil
+}
+
+type LoginThrottleScope string
+
+const (
+	LoginThrottleScopeAccount            LoginThrottleScope = "account"
+	LoginThrottleScopeIp                 LoginThrottleScope = "ip"
+	LoginThrottleScopePasswordResetEmail LoginThrottleScope = "password_reset_email"
+	LoginThrottleScopePasswordResetIp    LoginThrottleScope = "password_reset_ip"
+)
+
+func (e *LoginThrottleScope) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = LoginThrottleScope(s)
+	case string:
+		*e = LoginThrottleScope(s)
+	default:
+		return fmt.Errorf("unsupported scan type for LoginThrottleScope: %T", src)
+	}
+	return nil
+}
+
+type NullLoginThrottleScope struct {
+	LoginThrottleScope LoginThrottleScope
+	Valid              bool // Valid is true if LoginThrottleScope is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullLoginThrottleScope) Scan(value interface{}) error {
+	if value == nil {
+		ns.LoginThrottleScope, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.LoginThrottleScope.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullLoginThrottleScope) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.LoginThrottleScope), nil
+}
+
+type SubscriptionStatus string
+
+const (
+	SubscriptionStatusActive   SubscriptionStatus = "active"
+	SubscriptionStatusPastDue  SubscriptionStatus = "past_due"
+	SubscriptionStatusCanceled SubscriptionStatus = "canceled"
+	SubscriptionStatusExpired  SubscriptionStatus = "expired"
+)
+
+func (e *SubscriptionStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = SubscriptionStatus(s)
+	case string:
+		*e = SubscriptionStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for SubscriptionStatus: %T", src)
+	}
+	return nil
+}
+
+type NullSubscriptionStatus struct {
+	SubscriptionStatus SubscriptionStatus
+	Valid              bool // Valid is true if SubscriptionStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullSubscriptionStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.SubscriptionStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.SubscriptionStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullSubscriptionStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.SubscriptionStatus), nil
+}
+
+type UserRole string
+
+const (
+	UserRoleUser      UserRole = "user"
+	UserRoleModerator UserRole = "moderator"
+	UserRoleAdmin     UserRole = "admin"
+)
+
+func (e *UserRole) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = UserRole(s)
+	case string:
+		*e = UserRole(s)
+	default:
+		return fmt.Errorf("unsupported scan type for UserRole: %T", src)
+	}
+	return nil
+}
+
+type NullUserRole struct {
+	UserRole UserRole
+	Valid    bool // Valid is true if UserRole is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullUserRole) Scan(value interface{}) error {
+	if value == nil {
+		ns.UserRole, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.UserRole.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullUserRole) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.UserRole), nil
+}
+
+type WebhookEventStatus string
+
+const (
+	WebhookEventStatusReceived  WebhookEventStatus = "received"
+	WebhookEventStatusProcessed WebhookEventStatus = "processed"
+	WebhookEventStatusIgnored   WebhookEventStatus = "ignored"
+	WebhookEventStatusFailed    WebhookEventStatus = "failed"
+)
+
+func (e *WebhookEventStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = WebhookEventStatus(s)
+	case string:
+		*e = WebhookEventStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for WebhookEventStatus: %T", src)
+	}
+	return nil
+}
+
+type NullWebhookEventStatus struct {
+	WebhookEventStatus WebhookEventStatus
+	Valid              bool // Valid is true if WebhookEventStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullWebhookEventStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.WebhookEventStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.WebhookEventStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullWebhookEventStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.WebhookEventStatus), nil
+}
+
+type BannedWord struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	Word      string
+	MatchMode BannedWordMatchMode
+}
+
 type Chirp struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	Body      string
 	UserID    uuid.UUID
+	InReplyTo uuid.NullUUID
+}
+
+type ChirpHashtag struct {
+	ChirpID   uuid.UUID
+	HashtagID uuid.UUID
+}
+
+type ChirpLike struct {
+	ChirpID   uuid.UUID
+	UserID    uuid.UUID
+	CreatedAt time.Time
+}
+
+type ChirpMention struct {
+	ChirpID uuid.UUID
+	UserID  uuid.UUID
+}
+
+type ChirpRevision struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	ChirpID   uuid.UUID
+	Body      string
+}
+
+type DataExport struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UserID      uuid.UUID
+	Status      DataExportStatus
+	Archive     []byte
+	CompletedAt sql.NullTime
+	ExpiresAt   sql.NullTime
+}
+
+type EmailVerificationToken struct {
+	TokenHash string
+	CreatedAt time.Time
+	UserID    uuid.UUID
+	Email     string
+	ExpiresAt time.Time
+	UsedAt    sql.NullTime
+}
+
+type Follow struct {
+	FollowerID uuid.UUID
+	FolloweeID uuid.UUID
+	CreatedAt  time.Time
+}
+
+type Hashtag struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	Tag       string
+}
+
+type LoginThrottle struct {
+	Scope          LoginThrottleScope
+	Subject        string
+	FailedAttempts int32
+	LastFailedAt   time.Time
+	LockedUntil    sql.NullTime
+}
+
+type PasswordResetToken struct {
+	TokenHash string
+	CreatedAt time.Time
+	UserID    uuid.UUID
+	ExpiresAt time.Time
+	UsedAt    sql.NullTime
+}
+
+type RecoveryCode struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UserID    uuid.UUID
+	CodeHash  string
+	UsedAt    sql.NullTime
 }
 
 type RefreshToken struct {
-	Token     string
+	TokenHash string
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	UserID    uuid.UUID
 	ExpiresAt time.Time
 	RevokedAt sql.NullTime
+	FamilyID  uuid.UUID
+	RotatedAt sql.NullTime
+	UserAgent string
+	IpAddress string
+}
+
+type Subscription struct {
+	ID               uuid.UUID
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	UserID           uuid.UUID
+	Plan             string
+	Status           SubscriptionStatus
+	CurrentPeriodEnd sql.NullTime
+	CanceledAt       sql.NullTime
 }
 
 type User struct {
-	ID             uuid.UUID
-	CreatedAt      time.Time
-	UpdatedAt      time.Time
-	Email          string
-	HashedPassword string
-	IsChirpyRed    bool
+	ID                  uuid.UUID
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+	Email               string
+	HashedPassword      string
+	Username            sql.NullString
+	DisplayName         string
+	Bio                 string
+	Role                UserRole
+	TokensInvalidBefore sql.NullTime
+	TotpSecret          sql.NullString
+	TotpEnabledAt       sql.NullTime
+	TotpLastStep        sql.NullInt64
+	EmailVerifiedAt     sql.NullTime
+	DeleteAfter         sql.NullTime
+}
+
+type WebhookEvent struct {
+	ID          uuid.UUID
+	EventID     string
+	EventType   string
+	Payload     string
+	ReceivedAt  time.Time
+	Status      WebhookEventStatus
+	Attempts    int32
+	Error       sql.NullString
+	ProcessedAt sql.NullTime
+	ClaimedAt   time.Time
 }
@@ -0,0 +1,172 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: subscriptions.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+const createSubscription = `-- name: CreateSubscription :one
+INSERT INTO subscriptions (
+    user_id,
+    plan,
+    status,
+    current_period_end
+) VALUES (
+    $1,
+    $2,
+    $3,
+    $4
+)
+RETURNING id, created_at, updated_at, user_id, plan, status, current_period_end, canceled_at
+`
+
+type CreateSubscriptionParams struct {
+	UserID           uuid.UUID
+	Plan             string
+	Status           SubscriptionStatus
+	CurrentPeriodEnd sql.NullTime
+}
+
+func (q *Queries) CreateSubscription(ctx context.Context, arg CreateSubscriptionParams) (Subscription, error) {
+	row := q.db.QueryRowContext(ctx, createSubscription,
+		arg.UserID,
+		arg.Plan,
+		arg.Status,
+		arg.CurrentPeriodEnd,
+	)
+	var i Subscription
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.Plan,
+		&i.Status,
+		&i.CurrentPeriodEnd,
+		&i.CanceledAt,
+	)
+	return i, err
+}
+
+const getCurrentSubscriptionForUpdate = `-- name: GetCurrentSubscriptionForUpdate :one
+SELECT id, created_at, updated_at, user_id, plan, status, current_period_end, canceled_at
+FROM subscriptions
+WHERE user_id = $1
+ORDER BY created_at DESC, id DESC
+LIMIT 1
+FOR UPDATE
+`
+
+func (q *Queries) GetCurrentSubscriptionForUpdate(ctx context.Context, userID uuid.UUID) (Subscription, error) {
+	row := q.db.QueryRowContext(ctx, getCurrentSubscriptionForUpdate, userID)
+	var i Subscription
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.Plan,
+		&i.Status,
+		&i.CurrentPeriodEnd,
+		&i.CanceledAt,
+	)
+	return i, err
+}
+
+const isChirpyRed = `-- name: IsChirpyRed :one
+SELECT user_is_chirpy_red($1::uuid)::boolean AS is_chirpy_red
+`
+
+func (q *Queries) IsChirpyRed(ctx context.Context, userID uuid.UUID) (bool, error) {
+	row := q.db.QueryRowContext(ctx, isChirpyRed, userID)
+	var is_chirpy_red bool
+	err := row.Scan(&is_chirpy_red)
+	return is_chirpy_red, err
+}
+
+const listSubscriptionsByUser = `-- name: ListSubscriptionsByUser :many
+SELECT id, created_at, updated_at, user_id, plan, status, current_period_end, canceled_at
+FROM subscriptions
+WHERE user_id = $1
+ORDER BY created_at, id
+`
+
+func (q *Queries) ListSubscriptionsByUser(ctx context.Context, userID uuid.UUID) ([]Subscription, error) {
+	rows, err := q.db.QueryContext(ctx, listSubscriptionsByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Subscription
+	for rows.Next() {
+		var i Subscription
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserID,
+			&i.Plan,
+			&i.Status,
+			&i.CurrentPeriodEnd,
+			&i.CanceledAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateSubscription = `-- name: UpdateSubscription :one
+UPDATE subscriptions
+SET
+    plan = $2,
+    status = $3,
+    current_period_end = $4,
+    canceled_at = $5
+WHERE id = $1
+RETURNING id, created_at, updated_at, user_id, plan, status, current_period_end, canceled_at
+`
+
+type UpdateSubscriptionParams struct {
+	ID               uuid.UUID
+	Plan             string
+	Status           SubscriptionStatus
+	CurrentPeriodEnd sql.NullTime
+	CanceledAt       sql.NullTime
+}
+
+func (q *Queries) UpdateSubscription(ctx context.Context, arg UpdateSubscriptionParams) (Subscription, error) {
+	row := q.db.QueryRowContext(ctx, updateSubscription,
+		arg.ID,
+		arg.Plan,
+		arg.Status,
+		arg.CurrentPeriodEnd,
+		arg.CanceledAt,
+	)
+	var i Subscription
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.Plan,
+		&i.Status,
+		&i.CurrentPeriodEnd,
+		&i.CanceledAt,
+	)
+	return i, err
+}
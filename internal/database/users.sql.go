@@ -7,29 +7,58 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
 )
 
+const cancelUserDeletion = `-- name: CancelUserDeletion :exec
+UPDATE users
+SET delete_after = NULL
+WHERE
+    id = $1 AND
+    delete_after IS NOT NULL
+`
+
+func (q *Queries) CancelUserDeletion(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, cancelUserDeletion, id)
+	return err
+}
+
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (
     email,
-    hashed_password
+    hashed_password,
+    username,
+    display_name,
+    bio
 )
 VALUES (
     $1,
-    $2
+    $2,
+    $3,
+    $4,
+    $5
 )
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, username, display_name, bio, role, tokens_invalid_before, totp_secret, totp_enabled_at, totp_last_step, email_verified_at, delete_after
 `
 
 type CreateUserParams struct {
 	Email          string
 	HashedPassword string
+	Username       sql.NullString
+	DisplayName    string
+	Bio            string
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
-	row := q.db.QueryRowContext(ctx, createUser, arg.Email, arg.HashedPassword)
+	row := q.db.QueryRowContext(ctx, createUser,
+		arg.Email,
+		arg.HashedPassword,
+		arg.Username,
+		arg.DisplayName,
+		arg.Bio,
+	)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -37,7 +66,16 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.UpdatedAt,
 		&i.Email,
 		&i.HashedPassword,
-		&i.IsChirpyRed,
+		&i.Username,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Role,
+		&i.TokensInvalidBefore,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+		&i.EmailVerifiedAt,
+		&i.DeleteAfter,
 	)
 	return i, err
 }
@@ -51,8 +89,41 @@ func (q *Queries) DeleteAllUsers(ctx context.Context) error {
 	return err
 }
 
+const disableTOTP = `-- name: DisableTOTP :exec
+UPDATE users
+SET totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = NULL
+WHERE id = $1
+`
+
+func (q *Queries) DisableTOTP(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, disableTOTP, id)
+	return err
+}
+
+const enableTOTP = `-- name: EnableTOTP :execrows
+UPDATE users
+SET totp_enabled_at = now(), totp_last_step = $2
+WHERE
+    id = $1 AND
+    totp_secret IS NOT NULL AND
+    totp_enabled_at IS NULL
+`
+
+type EnableTOTPParams struct {
+	ID           uuid.UUID
+	TotpLastStep sql.NullInt64
+}
+
+func (q *Queries) EnableTOTP(ctx context.Context, arg EnableTOTPParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, enableTOTP, arg.ID, arg.TotpLastStep)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red
+SELECT id, created_at, updated_at, email, hashed_password, username, display_name, bio, role, tokens_invalid_before, totp_secret, totp_enabled_at, totp_last_step, email_verified_at, delete_after
 FROM users
 WHERE users.email = $1
 `
@@ -66,13 +137,22 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.UpdatedAt,
 		&i.Email,
 		&i.HashedPassword,
-		&i.IsChirpyRed,
+		&i.Username,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Role,
+		&i.TokensInvalidBefore,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+		&i.EmailVerifiedAt,
+		&i.DeleteAfter,
 	)
 	return i, err
 }
 
 const getUserById = `-- name: GetUserById :one
-SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red
+SELECT id, created_at, updated_at, email, hashed_password, username, display_name, bio, role, tokens_invalid_before, totp_secret, totp_enabled_at, totp_last_step, email_verified_at, delete_after
 FROM users
 WHERE users.id = $1
 `
@@ -86,24 +166,33 @@ func (q *Queries) GetUserById(ctx context.Context, id uuid.UUID) (User, error) {
 		&i.UpdatedAt,
 		&i.Email,
 		&i.HashedPassword,
-		&i.IsChirpyRed,
+		&i.Username,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Role,
+		&i.TokensInvalidBefore,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+		&i.EmailVerifiedAt,
+		&i.DeleteAfter,
 	)
 	return i, err
 }
 
 const getUserByRefreshToken = `-- name: GetUserByRefreshToken :one
-SELECT users.id, users.created_at, users.updated_at, users.email, users.hashed_password, users.is_chirpy_red
+SELECT users.id, users.created_at, users.updated_at, users.email, users.hashed_password, users.username, users.display_name, users.bio, users.role, users.tokens_invalid_before, users.totp_secret, users.totp_enabled_at, users.totp_last_step, users.email_verified_at, users.delete_after
 FROM users
 INNER JOIN refresh_tokens
 ON users.id = refresh_tokens.user_id
 WHERE
-    refresh_tokens.token = $1 AND
+    refresh_tokens.token_hash = $1 AND
     refresh_tokens.expires_at > now() AND
     refresh_tokens.revoked_at IS NULL
 `
 
-func (q *Queries) GetUserByRefreshToken(ctx context.Context, token string) (User, error) {
-	row := q.db.QueryRowContext(ctx, getUserByRefreshToken, token)
+func (q *Queries) GetUserByRefreshToken(ctx context.Context, tokenHash string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByRefreshToken, tokenHash)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -111,26 +200,279 @@ func (q *Queries) GetUserByRefreshToken(ctx context.Context, token string) (User
 		&i.UpdatedAt,
 		&i.Email,
 		&i.HashedPassword,
+		&i.Username,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Role,
+		&i.TokensInvalidBefore,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+		&i.EmailVerifiedAt,
+		&i.DeleteAfter,
+	)
+	return i, err
+}
+
+const getUserProfileById = `-- name: GetUserProfileById :one
+SELECT users.id, users.created_at, users.updated_at, users.email, users.hashed_password, users.username, users.display_name, users.bio, users.role, users.tokens_invalid_before, users.totp_secret, users.totp_enabled_at, users.totp_last_step, users.email_verified_at, users.delete_after, (
+    SELECT count(*)
+    FROM chirps
+    WHERE chirps.user_id = users.id
+) AS chirp_count, user_is_chirpy_red(users.id)::boolean AS is_chirpy_red
+FROM users
+WHERE users.id = $1
+`
+
+type GetUserProfileByIdRow struct {
+	User        User
+	ChirpCount  int64
+	IsChirpyRed bool
+}
+
+func (q *Queries) GetUserProfileById(ctx context.Context, id uuid.UUID) (GetUserProfileByIdRow, error) {
+	row := q.db.QueryRowContext(ctx, getUserProfileById, id)
+	var i GetUserProfileByIdRow
+	err := row.Scan(
+		&i.User.ID,
+		&i.User.CreatedAt,
+		&i.User.UpdatedAt,
+		&i.User.Email,
+		&i.User.HashedPassword,
+		&i.User.Username,
+		&i.User.DisplayName,
+		&i.User.Bio,
+		&i.User.Role,
+		&i.User.TokensInvalidBefore,
+		&i.User.TotpSecret,
+		&i.User.TotpEnabledAt,
+		&i.User.TotpLastStep,
+		&i.User.EmailVerifiedAt,
+		&i.User.DeleteAfter,
+		&i.ChirpCount,
 		&i.IsChirpyRed,
 	)
 	return i, err
 }
 
-const updateEmailAndPassword = `-- name: UpdateEmailAndPassword :one
+const getUserProfileByUsername = `-- name: GetUserProfileByUsername :one
+SELECT users.id, users.created_at, users.updated_at, users.email, users.hashed_password, users.username, users.display_name, users.bio, users.role, users.tokens_invalid_before, users.totp_secret, users.totp_enabled_at, users.totp_last_step, users.email_verified_at, users.delete_after, (
+    SELECT count(*)
+    FROM chirps
+    WHERE chirps.user_id = users.id
+) AS chirp_count, user_is_chirpy_red(users.id)::boolean AS is_chirpy_red
+FROM users
+WHERE lower(users.username) = lower($1)
+`
+
+type GetUserProfileByUsernameRow struct {
+	User        User
+	ChirpCount  int64
+	IsChirpyRed bool
+}
+
+func (q *Queries) GetUserProfileByUsername(ctx context.Context, username string) (GetUserProfileByUsernameRow, error) {
+	row := q.db.QueryRowContext(ctx, getUserProfileByUsername, username)
+	var i GetUserProfileByUsernameRow
+	err := row.Scan(
+		&i.User.ID,
+		&i.User.CreatedAt,
+		&i.User.UpdatedAt,
+		&i.User.Email,
+		&i.User.HashedPassword,
+		&i.User.Username,
+		&i.User.DisplayName,
+		&i.User.Bio,
+		&i.User.Role,
+		&i.User.TokensInvalidBefore,
+		&i.User.TotpSecret,
+		&i.User.TotpEnabledAt,
+		&i.User.TotpLastStep,
+		&i.User.EmailVerifiedAt,
+		&i.User.DeleteAfter,
+		&i.ChirpCount,
+		&i.IsChirpyRed,
+	)
+	return i, err
+}
+
+const getUserTokensInvalidBefore = `-- name: GetUserTokensInvalidBefore :one
+SELECT tokens_invalid_before
+FROM users
+WHERE id = $1
+`
+
+func (q *Queries) GetUserTokensInvalidBefore(ctx context.Context, id uuid.UUID) (sql.NullTime, error) {
+	row := q.db.QueryRowContext(ctx, getUserTokensInvalidBefore, id)
+	var tokens_invalid_before sql.NullTime
+	err := row.Scan(&tokens_invalid_before)
+	return tokens_invalid_before, err
+}
+
+const invalidateUserTokens = `-- name: InvalidateUserTokens :exec
+UPDATE users
+SET tokens_invalid_before = $2
+WHERE id = $1
+`
+
+type InvalidateUserTokensParams struct {
+	ID                  uuid.UUID
+	TokensInvalidBefore sql.NullTime
+}
+
+func (q *Queries) InvalidateUserTokens(ctx context.Context, arg InvalidateUserTokensParams) error {
+	_, err := q.db.ExecContext(ctx, invalidateUserTokens, arg.ID, arg.TokensInvalidBefore)
+	return err
+}
+
+const purgeDeletedUsers = `-- name: PurgeDeletedUsers :many
+DELETE FROM users
+WHERE delete_after <= now()
+RETURNING id
+`
+
+func (q *Queries) PurgeDeletedUsers(ctx context.Context) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, purgeDeletedUsers)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const recordTOTPStep = `-- name: RecordTOTPStep :execrows
+UPDATE users
+SET totp_last_step = $2
+WHERE
+    id = $1 AND
+    (totp_last_step IS NULL OR totp_last_step < $2)
+`
+
+type RecordTOTPStepParams struct {
+	ID           uuid.UUID
+	TotpLastStep sql.NullInt64
+}
+
+// Each time step is accepted at most once, so a code seen by an attacker
+// cannot be replayed within its validity window.
+func (q *Queries) RecordTOTPStep(ctx context.Context, arg RecordTOTPStepParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, recordTOTPStep, arg.ID, arg.TotpLastStep)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const scheduleUserDeletion = `-- name: ScheduleUserDeletion :one
+UPDATE users
+SET delete_after = $2
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, username, display_name, bio, role, tokens_invalid_before, totp_secret, totp_enabled_at, totp_last_step, email_verified_at, delete_after
+`
+
+type ScheduleUserDeletionParams struct {
+	ID          uuid.UUID
+	DeleteAfter sql.NullTime
+}
+
+func (q *Queries) ScheduleUserDeletion(ctx context.Context, arg ScheduleUserDeletionParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, scheduleUserDeletion, arg.ID, arg.DeleteAfter)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.Username,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Role,
+		&i.TokensInvalidBefore,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+		&i.EmailVerifiedAt,
+		&i.DeleteAfter,
+	)
+	return i, err
+}
+
+const setPendingTOTPSecret = `-- name: SetPendingTOTPSecret :execrows
+UPDATE users
+SET totp_secret = $2, totp_last_step = NULL
+WHERE
+    id = $1 AND
+    totp_enabled_at IS NULL
+`
+
+type SetPendingTOTPSecretParams struct {
+	ID         uuid.UUID
+	TotpSecret sql.NullString
+}
+
+func (q *Queries) SetPendingTOTPSecret(ctx context.Context, arg SetPendingTOTPSecretParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setPendingTOTPSecret, arg.ID, arg.TotpSecret)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updatePassword = `-- name: UpdatePassword :exec
 UPDATE users
-SET email = $2, hashed_password = $3
+SET hashed_password = $2
 WHERE id = $1
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
 `
 
-type UpdateEmailAndPasswordParams struct {
+type UpdatePasswordParams struct {
 	ID             uuid.UUID
-	Email          string
 	HashedPassword string
 }
 
-func (q *Queries) UpdateEmailAndPassword(ctx context.Context, arg UpdateEmailAndPasswordParams) (User, error) {
-	row := q.db.QueryRowContext(ctx, updateEmailAndPassword, arg.ID, arg.Email, arg.HashedPassword)
+func (q *Queries) UpdatePassword(ctx context.Context, arg UpdatePasswordParams) error {
+	_, err := q.db.ExecContext(ctx, updatePassword, arg.ID, arg.HashedPassword)
+	return err
+}
+
+const updateProfile = `-- name: UpdateProfile :one
+UPDATE users
+SET
+    username = coalesce($1, username),
+    display_name = coalesce($2, display_name),
+    bio = coalesce($3, bio)
+WHERE id = $4
+RETURNING id, created_at, updated_at, email, hashed_password, username, display_name, bio, role, tokens_invalid_before, totp_secret, totp_enabled_at, totp_last_step, email_verified_at, delete_after
+`
+
+type UpdateProfileParams struct {
+	Username    sql.NullString
+	DisplayName sql.NullString
+	Bio         sql.NullString
+	ID          uuid.UUID
+}
+
+func (q *Queries) UpdateProfile(ctx context.Context, arg UpdateProfileParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateProfile,
+		arg.Username,
+		arg.DisplayName,
+		arg.Bio,
+		arg.ID,
+	)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -138,20 +480,34 @@ func (q *Queries) UpdateEmailAndPassword(ctx context.Context, arg UpdateEmailAnd
 		&i.UpdatedAt,
 		&i.Email,
 		&i.HashedPassword,
-		&i.IsChirpyRed,
+		&i.Username,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Role,
+		&i.TokensInvalidBefore,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+		&i.EmailVerifiedAt,
+		&i.DeleteAfter,
 	)
 	return i, err
 }
 
-const upgradeToRed = `-- name: UpgradeToRed :one
+const updateRole = `-- name: UpdateRole :one
 UPDATE users
-SET is_chirpy_red = true
+SET role = $2
 WHERE id = $1
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, username, display_name, bio, role, tokens_invalid_before, totp_secret, totp_enabled_at, totp_last_step, email_verified_at, delete_after
 `
 
-func (q *Queries) UpgradeToRed(ctx context.Context, id uuid.UUID) (User, error) {
-	row := q.db.QueryRowContext(ctx, upgradeToRed, id)
+type UpdateRoleParams struct {
+	ID   uuid.UUID
+	Role UserRole
+}
+
+func (q *Queries) UpdateRole(ctx context.Context, arg UpdateRoleParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateRole, arg.ID, arg.Role)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -159,7 +515,51 @@ func (q *Queries) UpgradeToRed(ctx context.Context, id uuid.UUID) (User, error)
 		&i.UpdatedAt,
 		&i.Email,
 		&i.HashedPassword,
-		&i.IsChirpyRed,
+		&i.Username,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Role,
+		&i.TokensInvalidBefore,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+		&i.EmailVerifiedAt,
+		&i.DeleteAfter,
+	)
+	return i, err
+}
+
+const verifyEmail = `-- name: VerifyEmail :one
+UPDATE users
+SET email = $2, email_verified_at = now()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, username, display_name, bio, role, tokens_invalid_before, totp_secret, totp_enabled_at, totp_last_step, email_verified_at, delete_after
+`
+
+type VerifyEmailParams struct {
+	ID    uuid.UUID
+	Email string
+}
+
+func (q *Queries) VerifyEmail(ctx context.Context, arg VerifyEmailParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, verifyEmail, arg.ID, arg.Email)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.Username,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Role,
+		&i.TokensInvalidBefore,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+		&i.EmailVerifiedAt,
+		&i.DeleteAfter,
 	)
 	return i, err
 }
@@ -0,0 +1,223 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: webhook_events.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const claimWebhookEvent = `-- name: ClaimWebhookEvent :one
+INSERT INTO webhook_events (
+    event_id,
+    event_type,
+    payload
+) VALUES (
+    $1,
+    $2,
+    $3
+)
+ON CONFLICT (event_id) DO UPDATE
+SET
+    status = 'received',
+    attempts = webhook_events.attempts + 1,
+    error = NULL,
+    claimed_at = now(),
+    processed_at = NULL
+WHERE
+    webhook_events.status = 'failed' OR
+    (webhook_events.status = 'received' AND webhook_events.claimed_at < $4)
+RETURNING id, event_id, event_type, payload, received_at, status, attempts, error, processed_at, claimed_at
+`
+
+type ClaimWebhookEventParams struct {
+	EventID     string
+	EventType   string
+	Payload     string
+	StaleBefore time.Time
+}
+
+// Claims an event for processing. A new event is inserted; a failed one, or
+// one whose processing was abandoned before stale_before, is claimed again
+// for a retry. Events that were already handled, or are being handled right
+// now, return no row.
+func (q *Queries) ClaimWebhookEvent(ctx context.Context, arg ClaimWebhookEventParams) (WebhookEvent, error) {
+	row := q.db.QueryRowContext(ctx, claimWebhookEvent,
+		arg.EventID,
+		arg.EventType,
+		arg.Payload,
+		arg.StaleBefore,
+	)
+	var i WebhookEvent
+	err := row.Scan(
+		&i.ID,
+		&i.EventID,
+		&i.EventType,
+		&i.Payload,
+		&i.ReceivedAt,
+		&i.Status,
+		&i.Attempts,
+		&i.Error,
+		&i.ProcessedAt,
+		&i.ClaimedAt,
+	)
+	return i, err
+}
+
+const finishWebhookEvent = `-- name: FinishWebhookEvent :one
+UPDATE webhook_events
+SET
+    status = $2,
+    error = $3,
+    processed_at = now()
+WHERE id = $1
+RETURNING id, event_id, event_type, payload, received_at, status, attempts, error, processed_at, claimed_at
+`
+
+type FinishWebhookEventParams struct {
+	ID     uuid.UUID
+	Status WebhookEventStatus
+	Error  sql.NullString
+}
+
+func (q *Queries) FinishWebhookEvent(ctx context.Context, arg FinishWebhookEventParams) (WebhookEvent, error) {
+	row := q.db.QueryRowContext(ctx, finishWebhookEvent, arg.ID, arg.Status, arg.Error)
+	var i WebhookEvent
+	err := row.Scan(
+		&i.ID,
+		&i.EventID,
+		&i.EventType,
+		&i.Payload,
+		&i.ReceivedAt,
+		&i.Status,
+		&i.Attempts,
+		&i.Error,
+		&i.ProcessedAt,
+		&i.ClaimedAt,
+	)
+	return i, err
+}
+
+const getWebhookEvent = `-- name: GetWebhookEvent :one
+SELECT id, event_id, event_type, payload, received_at, status, attempts, error, processed_at, claimed_at
+FROM webhook_events
+WHERE id = $1
+`
+
+func (q *Queries) GetWebhookEvent(ctx context.Context, id uuid.UUID) (WebhookEvent, error) {
+	row := q.db.QueryRowContext(ctx, getWebhookEvent, id)
+	var i WebhookEvent
+	err := row.Scan(
+		&i.ID,
+		&i.EventID,
+		&i.EventType,
+		&i.Payload,
+		&i.ReceivedAt,
+		&i.Status,
+		&i.Attempts,
+		&i.Error,
+		&i.ProcessedAt,
+		&i.ClaimedAt,
+	)
+	return i, err
+}
+
+const listWebhookEvents = `-- name: ListWebhookEvents :many
+SELECT id, event_id, event_type, payload, received_at, status, attempts, error, processed_at, claimed_at
+FROM webhook_events
+WHERE
+    ($1::webhook_event_status IS NULL OR webhook_events.status = $1) AND
+    ($2::timestamptz IS NULL OR
+        (webhook_events.received_at, webhook_events.id) < ($2, $3::uuid))
+ORDER BY webhook_events.received_at DESC, webhook_events.id DESC
+LIMIT $4
+`
+
+type ListWebhookEventsParams struct {
+	Status           NullWebhookEventStatus
+	BeforeReceivedAt sql.NullTime
+	BeforeID         uuid.NullUUID
+	Limit            int32
+}
+
+func (q *Queries) ListWebhookEvents(ctx context.Context, arg ListWebhookEventsParams) ([]WebhookEvent, error) {
+	rows, err := q.db.QueryContext(ctx, listWebhookEvents,
+		arg.Status,
+		arg.BeforeReceivedAt,
+		arg.BeforeID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WebhookEvent
+	for rows.Next() {
+		var i WebhookEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.EventID,
+			&i.EventType,
+			&i.Payload,
+			&i.ReceivedAt,
+			&i.Status,
+			&i.Attempts,
+			&i.Error,
+			&i.ProcessedAt,
+			&i.ClaimedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const reclaimWebhookEvent = `-- name: ReclaimWebhookEvent :one
+UPDATE webhook_events
+SET
+    status = 'received',
+    attempts = attempts + 1,
+    error = NULL,
+    claimed_at = now(),
+    processed_at = NULL
+WHERE
+    id = $1 AND
+    (status = 'failed' OR (status = 'received' AND claimed_at < $2))
+RETURNING id, event_id, event_type, payload, received_at, status, attempts, error, processed_at, claimed_at
+`
+
+type ReclaimWebhookEventParams struct {
+	ID          uuid.UUID
+	StaleBefore time.Time
+}
+
+func (q *Queries) ReclaimWebhookEvent(ctx context.Context, arg ReclaimWebhookEventParams) (WebhookEvent, error) {
+	row := q.db.QueryRowContext(ctx, reclaimWebhookEvent, arg.ID, arg.StaleBefore)
+	var i WebhookEvent
+	err := row.Scan(
+		&i.ID,
+		&i.EventID,
+		&i.EventType,
+		&i.Payload,
+		&i.ReceivedAt,
+		&i.Status,
+		&i.Attempts,
+		&i.Error,
+		&i.ProcessedAt,
+		&i.ClaimedAt,
+	)
+	return i, err
+}
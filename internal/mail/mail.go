@@ -0,0 +1,183 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/smtp"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers messages to users.
+type Mailer interface {
+	Send(ctx context.Context, message Message) error
+}
+
+// SMTPMailer delivers messages through an SMTP server, authenticating with
+// PLAIN auth when a username is set.
+type SMTPMailer struct {
+	Addr     string
+	From     string
+	Username string
+	Password string
+}
+
+// Send delivers the message in a single SMTP session. The connection is
+// closed when ctx is done, so a slow or unresponsive server cannot hold up
+// the caller past its deadline.
+func (mailer *SMTPMailer) Send(ctx context.Context, message Message) error {
+	data, err := formatMessage(mailer.From, message, time.Now())
+	if err != nil {
+		return err
+	}
+
+	host, _, err := net.SplitHostPort(mailer.Addr)
+	if err != nil {
+		return err
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", mailer.Addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		err = conn.SetDeadline(deadline)
+		if err != nil {
+			return err
+		}
+	}
+
+	stop := context.AfterFunc(ctx, func() {
+		conn.Close()
+	})
+	defer stop()
+
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return contextError(ctx, err)
+	}
+	defer client.Close()
+
+	err = mailer.deliver(client, host, message.To, data)
+	return contextError(ctx, err)
+}
+
+func (mailer *SMTPMailer) deliver(client *smtp.Client, host string, to string, data []byte) error {
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		err := client.StartTLS(&tls.Config{ServerName: host})
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(mailer.Username) > 0 {
+		err := client.Auth(smtp.PlainAuth("", mailer.Username, mailer.Password, host))
+		if err != nil {
+			return err
+		}
+	}
+
+	err := client.Mail(mailer.From)
+	if err != nil {
+		return err
+	}
+
+	err = client.Rcpt(to)
+	if err != nil {
+		return err
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+
+	_, err = writer.Write(data)
+	if err != nil {
+		return err
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+// contextError reports the context's error in place of the network error
+// caused by closing the connection when ctx is done.
+func contextError(ctx context.Context, err error) error {
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// LogMailer writes messages to a writer instead of delivering them, for
+// local development where no SMTP server is available.
+type LogMailer struct {
+	From string
+
+	mu     sync.Mutex
+	writer io.Writer
+}
+
+func NewLogMailer(from string, writer io.Writer) *LogMailer {
+	return &LogMailer{
+		From:   from,
+		writer: writer,
+	}
+}
+
+func (mailer *LogMailer) Send(ctx context.Context, message Message) error {
+	data, err := formatMessage(mailer.From, message, time.Now())
+	if err != nil {
+		return err
+	}
+
+	mailer.mu.Lock()
+	defer mailer.mu.Unlock()
+
+	_, err = fmt.Fprintf(mailer.writer, "%s\r\n.\r\n", data)
+	return err
+}
+
+// formatMessage renders a plain text message with the headers SMTP servers
+// expect. Header values come from the application, but line breaks are still
+// rejected so a crafted address cannot inject extra headers.
+func formatMessage(from string, message Message, date time.Time) ([]byte, error) {
+	for _, value := range []string{from, message.To, message.Subject} {
+		if strings.ContainsAny(value, "\r\n") {
+			return nil, fmt.Errorf("mail header contains a line break")
+		}
+	}
+
+	body := strings.ReplaceAll(message.Body, "\r\n", "\n")
+	body = strings.ReplaceAll(body, "\n", "\r\n")
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "From: %s\r\n", from)
+	fmt.Fprintf(&builder, "To: %s\r\n", message.To)
+	fmt.Fprintf(&builder, "Subject: %s\r\n", message.Subject)
+	fmt.Fprintf(&builder, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	builder.WriteString("MIME-Version: 1.0\r\n")
+	builder.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	builder.WriteString("\r\n")
+	builder.WriteString(body)
+
+	return []byte(builder.String()), nil
+}
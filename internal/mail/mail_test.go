@@ -0,0 +1,119 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatMessage(t *testing.T) {
+	date := time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		from          string
+		message       Message
+		wantErr       bool
+		expectedValue string
+	}{
+		{
+			name: "Plain message",
+			from: "chirpy@example.com",
+			message: Message{
+				To:      "user@example.com",
+				Subject: "Hello",
+				Body:    "line one\nline two",
+			},
+			wantErr: false,
+			expectedValue: "From: chirpy@example.com\r\n" +
+				"To: user@example.com\r\n" +
+				"Subject: Hello\r\n" +
+				"Date: Sun, 01 Mar 2026 12:00:00 +0000\r\n" +
+				"MIME-Version: 1.0\r\n" +
+				"Content-Type: text/plain; charset=UTF-8\r\n" +
+				"\r\n" +
+				"line one\r\nline two",
+		},
+		{
+			name: "Header injection",
+			from: "chirpy@example.com",
+			message: Message{
+				To:      "user@example.com\r\nBcc: other@example.com",
+				Subject: "Hello",
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actualValue, err := formatMessage(tt.from, tt.message, date)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("formatMessage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && string(actualValue) != tt.expectedValue {
+				t.Errorf("formatMessage() expects %q, got %q", tt.expectedValue, actualValue)
+			}
+		})
+	}
+}
+
+func TestLogMailer(t *testing.T) {
+	var output bytes.Buffer
+	mailer := NewLogMailer("chirpy@example.com", &output)
+
+	err := mailer.Send(context.Background(), Message{
+		To:      "user@example.com",
+		Subject: "Reset your password",
+		Body:    "token",
+	})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	for _, expected := range []string{"To: user@example.com\r\n", "Subject: Reset your password\r\n", "\r\n\r\ntoken"} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("Send() output expects %q, got %q", expected, output.String())
+		}
+	}
+}
+
+func TestSMTPMailerStopsAtDeadline(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	defer listener.Close()
+
+	// The server accepts the connection but never sends its greeting.
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		io.Copy(io.Discard, conn)
+	}()
+
+	mailer := &SMTPMailer{
+		Addr: listener.Addr().String(),
+		From: "chirpy@example.com",
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	err = mailer.Send(ctx, Message{
+		To:      "user@example.com",
+		Subject: "Reset your password",
+		Body:    "token",
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Send() error = %v, expected %v", err, context.DeadlineExceeded)
+	}
+}
@@ -0,0 +1,77 @@
+package profanity
+
+import (
+	"fmt"
+	"regexp"
+)
+
+type MatchMode string
+
+const (
+	// WholeWord only matches the word when it is not part of a longer word.
+	WholeWord MatchMode = "whole_word"
+	// Substring matches the word anywhere, including inside other words.
+	Substring MatchMode = "substring"
+	// Regex treats the word as an RE2 regular expression.
+	Regex MatchMode = "regex"
+)
+
+const Replacement = "****"
+
+type Rule struct {
+	Word string
+	Mode MatchMode
+}
+
+// Filter censors text that matches any of its rules. The zero value censors
+// nothing. All matching is case-insensitive.
+type Filter struct {
+	regexps []*regexp.Regexp
+}
+
+func CompileRule(rule Rule) (*regexp.Regexp, error) {
+	if len(rule.Word) == 0 {
+		return nil, fmt.Errorf("word is empty")
+	}
+
+	switch rule.Mode {
+	case WholeWord:
+		return regexp.Compile(`(?i)\b` + regexp.QuoteMeta(rule.Word) + `\b`)
+	case Substring:
+		return regexp.Compile(`(?i)` + regexp.QuoteMeta(rule.Word))
+	case Regex:
+		re, err := regexp.Compile(`(?i)` + rule.Word)
+		if err != nil {
+			return nil, fmt.Errorf("word is not a valid regular expression: %w", err)
+		}
+		if re.MatchString("") {
+			return nil, fmt.Errorf("word must not match empty text")
+		}
+		return re, nil
+	default:
+		return nil, fmt.Errorf("match mode %q is not supported", rule.Mode)
+	}
+}
+
+func NewFilter(rules []Rule) (*Filter, error) {
+	filter := &Filter{
+		regexps: make([]*regexp.Regexp, len(rules)),
+	}
+
+	for index, rule := range rules {
+		re, err := CompileRule(rule)
+		if err != nil {
+			return nil, fmt.Errorf("banned word %q: %w", rule.Word, err)
+		}
+		filter.regexps[index] = re
+	}
+
+	return filter, nil
+}
+
+func (filter *Filter) Censor(text string) string {
+	for _, re := range filter.regexps {
+		text = re.ReplaceAllString(text, Replacement)
+	}
+	return text
+}
@@ -0,0 +1,108 @@
+package profanity
+
+import "testing"
+
+func TestCompileRule(t *testing.T) {
+	tests := []struct {
+		name          string
+		rule          Rule
+		expectedError bool
+	}{
+		{
+			name:          "Whole word",
+			rule:          Rule{Word: "kerfuffle", Mode: WholeWord},
+			expectedError: false,
+		},
+		{
+			name:          "Substring with regex characters",
+			rule:          Rule{Word: "a.b*", Mode: Substring},
+			expectedError: false,
+		},
+		{
+			name:          "Valid regex",
+			rule:          Rule{Word: `sharb(e|i)rt`, Mode: Regex},
+			expectedError: false,
+		},
+		{
+			name:          "Invalid regex",
+			rule:          Rule{Word: `sharb(e|i`, Mode: Regex},
+			expectedError: true,
+		},
+		{
+			name:          "Regex matching empty text",
+			rule:          Rule{Word: `x*`, Mode: Regex},
+			expectedError: true,
+		},
+		{
+			name:          "Empty word",
+			rule:          Rule{Word: "", Mode: WholeWord},
+			expectedError: true,
+		},
+		{
+			name:          "Unknown mode",
+			rule:          Rule{Word: "fornax", Mode: "fuzzy"},
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := CompileRule(tt.rule)
+			if (err != nil) != tt.expectedError {
+				t.Errorf("CompileRule() error = %v, expectedError %v", err, tt.expectedError)
+			}
+		})
+	}
+}
+
+func TestFilterCensor(t *testing.T) {
+	filter, err := NewFilter([]Rule{
+		{Word: "kerfuffle", Mode: WholeWord},
+		{Word: "fornax", Mode: Substring},
+		{Word: `sharb(e|i)rt`, Mode: Regex},
+	})
+	if err != nil {
+		t.Fatalf("NewFilter() error = %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		text          string
+		expectedValue string
+	}{
+		{
+			name:          "Clean text",
+			text:          "This is a nice chirp",
+			expectedValue: "This is a nice chirp",
+		},
+		{
+			name:          "Whole word is case-insensitive",
+			text:          "What a Kerfuffle today",
+			expectedValue: "What a **** today",
+		},
+		{
+			name:          "Whole word ignores longer words",
+			text:          "kerfuffles everywhere",
+			expectedValue: "kerfuffles everywhere",
+		},
+		{
+			name:          "Substring inside a word",
+			text:          "superfornaxy",
+			expectedValue: "super****y",
+		},
+		{
+			name:          "Regex alternatives",
+			text:          "sharbert or sharbirt",
+			expectedValue: "**** or ****",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actualValue := filter.Censor(tt.text)
+			if actualValue != tt.expectedValue {
+				t.Errorf("Censor() expects %q, got %q", tt.expectedValue, actualValue)
+			}
+		})
+	}
+}
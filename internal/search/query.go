@@ -0,0 +1,54 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// ToTSQuery converts free text search input into to_tsquery syntax. Terms are
+// combined with AND, "quoted phrases" must match adjacent words, and a term
+// ending in * matches any word with that prefix. Everything other than
+// letters and digits is discarded so the result is always a valid tsquery.
+func ToTSQuery(input string) (string, error) {
+	terms := []string{}
+
+	for index, segment := range strings.Split(input, `"`) {
+		isPhrase := index%2 == 1
+		if isPhrase {
+			if term := phraseTerm(segment); len(term) > 0 {
+				terms = append(terms, term)
+			}
+			continue
+		}
+
+		for _, word := range strings.Fields(segment) {
+			if term := phraseTerm(word); len(term) > 0 {
+				terms = append(terms, term)
+			}
+		}
+	}
+
+	if len(terms) == 0 {
+		return "", fmt.Errorf("search query is empty")
+	}
+
+	return strings.Join(terms, " & "), nil
+}
+
+func phraseTerm(text string) string {
+	isPrefix := strings.HasSuffix(strings.TrimSpace(text), "*")
+
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) == 0 {
+		return ""
+	}
+
+	if isPrefix {
+		words[len(words)-1] += ":*"
+	}
+
+	return strings.Join(words, " <-> ")
+}
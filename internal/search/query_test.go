@@ -0,0 +1,79 @@
+package search
+
+import "testing"
+
+func TestToTSQuery(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		expectedError bool
+		expectedValue string
+	}{
+		{
+			name:          "Empty input",
+			input:         "",
+			expectedError: true,
+			expectedValue: "",
+		},
+		{
+			name:          "Only punctuation",
+			input:         `"" & | !`,
+			expectedError: true,
+			expectedValue: "",
+		},
+		{
+			name:          "Single word",
+			input:         "chirpy",
+			expectedError: false,
+			expectedValue: "chirpy",
+		},
+		{
+			name:          "Multiple words",
+			input:         "  hello   world ",
+			expectedError: false,
+			expectedValue: "hello & world",
+		},
+		{
+			name:          "Prefix word",
+			input:         "chir*",
+			expectedError: false,
+			expectedValue: "chir:*",
+		},
+		{
+			name:          "Quoted phrase",
+			input:         `"hello world" again`,
+			expectedError: false,
+			expectedValue: "hello <-> world & again",
+		},
+		{
+			name:          "Quoted phrase with prefix",
+			input:         `"hello wor*"`,
+			expectedError: false,
+			expectedValue: "hello <-> wor:*",
+		},
+		{
+			name:          "Unterminated quote",
+			input:         `boot "dev chirpy`,
+			expectedError: false,
+			expectedValue: "boot & dev <-> chirpy",
+		},
+		{
+			name:          "Operators are stripped",
+			input:         "a&b | !c:* (d)",
+			expectedError: false,
+			expectedValue: "a <-> b & c:* & d",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actualValue, err := ToTSQuery(tt.input)
+			if (err != nil) != tt.expectedError {
+				t.Errorf("ToTSQuery() error = %v, expectedError %v", err, tt.expectedError)
+			}
+			if actualValue != tt.expectedValue {
+				t.Errorf("ToTSQuery() expects %q, got %q", tt.expectedValue, actualValue)
+			}
+		})
+	}
+}
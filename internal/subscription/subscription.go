@@ -0,0 +1,191 @@
+package subscription
+
+import (
+	"errors"
+	"time"
+)
+
+// DefaultPlan is the plan of subscriptions started without one.
+const DefaultPlan = "chirpy_red"
+
+// Status mirrors the subscription_status enum.
+type Status string
+
+const (
+	StatusActive   Status = "active"
+	StatusPastDue  Status = "past_due"
+	StatusCanceled Status = "canceled"
+	StatusExpired  Status = "expired"
+)
+
+var ErrNotFound = errors.New("subscription not found")
+
+// Subscription is the part of a user's subscription that billing events
+// change.
+type Subscription struct {
+	Plan             string
+	Status           Status
+	CurrentPeriodEnd *time.Time
+	CanceledAt       *time.Time
+}
+
+// Action says what to do with the result of a transition.
+type Action int
+
+const (
+	// Skip leaves the current subscription as it is.
+	Skip Action = iota
+	// Update replaces the current subscription's fields.
+	Update
+	// Create starts a new subscription and keeps the current one as history.
+	Create
+)
+
+type Change struct {
+	Action       Action
+	Subscription Subscription
+}
+
+// Transition applies an event to the user's current subscription, which is
+// nil when the user never had one.
+type Transition func(current *Subscription, now time.Time) (Change, error)
+
+// Ended mirrors user_is_chirpy_red: a subscription has ended once it expired
+// or its period is over.
+func Ended(subscription Subscription, now time.Time) bool {
+	if subscription.Status == StatusExpired {
+		return true
+	}
+	return subscription.CurrentPeriodEnd != nil && !subscription.CurrentPeriodEnd.After(now)
+}
+
+// Upgrade starts a subscription, or brings back the current one when it is
+// active or past due and has not ended yet. A canceled or ended subscription
+// is kept as history and a new one is started.
+func Upgrade(plan string, periodEnd *time.Time) Transition {
+	if len(plan) == 0 {
+		plan = DefaultPlan
+	}
+
+	return func(current *Subscription, now time.Time) (Change, error) {
+		if current != nil && !Ended(*current, now) &&
+			(current.Status == StatusActive || current.Status == StatusPastDue) {
+			return update(Subscription{
+				Plan:             plan,
+				Status:           StatusActive,
+				CurrentPeriodEnd: firstSet(periodEnd, current.CurrentPeriodEnd),
+			}), nil
+		}
+
+		return create(Subscription{
+			Plan:             plan,
+			Status:           StatusActive,
+			CurrentPeriodEnd: periodEnd,
+		}), nil
+	}
+}
+
+// Renew extends the current subscription. Once that one has ended, by a
+// downgrade or by its period running out, the renewal starts a new
+// subscription on the same plan instead, since the old period end would keep
+// it from counting.
+func Renew(periodEnd *time.Time) Transition {
+	return func(current *Subscription, now time.Time) (Change, error) {
+		if current == nil {
+			return Change{}, ErrNotFound
+		}
+
+		if Ended(*current, now) {
+			return create(Subscription{
+				Plan:             current.Plan,
+				Status:           StatusActive,
+				CurrentPeriodEnd: periodEnd,
+			}), nil
+		}
+
+		return update(Subscription{
+			Plan:             current.Plan,
+			Status:           StatusActive,
+			CurrentPeriodEnd: firstSet(periodEnd, current.CurrentPeriodEnd),
+		}), nil
+	}
+}
+
+// Cancel stops renewal. The user keeps the subscription until the end of the
+// period already paid for, or loses it right away when neither the event nor
+// the subscription says when that is. An expired subscription stays expired.
+func Cancel(periodEnd *time.Time) Transition {
+	return func(current *Subscription, now time.Time) (Change, error) {
+		if current == nil {
+			return Change{}, ErrNotFound
+		}
+
+		if current.Status == StatusExpired {
+			return Change{Action: Skip}, nil
+		}
+
+		return update(Subscription{
+			Plan:             current.Plan,
+			Status:           StatusCanceled,
+			CurrentPeriodEnd: firstSet(periodEnd, current.CurrentPeriodEnd, &now),
+			CanceledAt:       &now,
+		}), nil
+	}
+}
+
+// FailPayment marks the subscription past due. The payment is retried, so the
+// user keeps the subscription until the period ends. An expired subscription
+// stays expired.
+func FailPayment() Transition {
+	return func(current *Subscription, now time.Time) (Change, error) {
+		if current == nil {
+			return Change{}, ErrNotFound
+		}
+
+		if current.Status == StatusExpired {
+			return Change{Action: Skip}, nil
+		}
+
+		return update(Subscription{
+			Plan:             current.Plan,
+			Status:           StatusPastDue,
+			CurrentPeriodEnd: current.CurrentPeriodEnd,
+			CanceledAt:       current.CanceledAt,
+		}), nil
+	}
+}
+
+// Downgrade ends the subscription immediately. Downgrading a user without one
+// is already done.
+func Downgrade() Transition {
+	return func(current *Subscription, now time.Time) (Change, error) {
+		if current == nil || current.Status == StatusExpired {
+			return Change{Action: Skip}, nil
+		}
+
+		return update(Subscription{
+			Plan:             current.Plan,
+			Status:           StatusExpired,
+			CurrentPeriodEnd: &now,
+			CanceledAt:       current.CanceledAt,
+		}), nil
+	}
+}
+
+func update(subscription Subscription) Change {
+	return Change{Action: Update, Subscription: subscription}
+}
+
+func create(subscription Subscription) Change {
+	return Change{Action: Create, Subscription: subscription}
+}
+
+// firstSet returns the first time that is set.
+func firstSet(times ...*time.Time) *time.Time {
+	for _, value := range times {
+		if value != nil {
+			return value
+		}
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package subscription
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTransitions(t *testing.T) {
+	now := time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+	lastMonth := now.AddDate(0, -1, 0)
+	nextMonth := now.AddDate(0, 1, 0)
+	inTwoMonths := now.AddDate(0, 2, 0)
+
+	active := &Subscription{Plan: "chirpy_red", Status: StatusActive, CurrentPeriodEnd: &nextMonth}
+	lapsed := &Subscription{Plan: "chirpy_red", Status: StatusActive, CurrentPeriodEnd: &lastMonth}
+	expired := &Subscription{Plan: "chirpy_red", Status: StatusExpired, CurrentPeriodEnd: &lastMonth}
+
+	tests := []struct {
+		name           string
+		current        *Subscription
+		transitions    []Transition
+		expectedError  error
+		expectedAction Action
+		expectedValue  *Subscription
+	}{
+		{
+			name:           "Upgrade without a subscription",
+			current:        nil,
+			transitions:    []Transition{Upgrade("", &nextMonth)},
+			expectedAction: Create,
+			expectedValue:  &Subscription{Plan: DefaultPlan, Status: StatusActive, CurrentPeriodEnd: &nextMonth},
+		},
+		{
+			name:           "Upgrade keeps the period end when the event has none",
+			current:        active,
+			transitions:    []Transition{Upgrade("chirpy_red", nil)},
+			expectedAction: Update,
+			expectedValue:  &Subscription{Plan: "chirpy_red", Status: StatusActive, CurrentPeriodEnd: &nextMonth},
+		},
+		{
+			name:           "Upgrade after the period ended",
+			current:        lapsed,
+			transitions:    []Transition{Upgrade("chirpy_red", &nextMonth)},
+			expectedAction: Create,
+			expectedValue:  &Subscription{Plan: "chirpy_red", Status: StatusActive, CurrentPeriodEnd: &nextMonth},
+		},
+		{
+			name:           "Renew extends the period",
+			current:        active,
+			transitions:    []Transition{Renew(&inTwoMonths)},
+			expectedAction: Update,
+			expectedValue:  &Subscription{Plan: "chirpy_red", Status: StatusActive, CurrentPeriodEnd: &inTwoMonths},
+		},
+		{
+			name:           "Renew after downgrade",
+			current:        active,
+			transitions:    []Transition{Downgrade(), Renew(&nextMonth)},
+			expectedAction: Create,
+			expectedValue:  &Subscription{Plan: "chirpy_red", Status: StatusActive, CurrentPeriodEnd: &nextMonth},
+		},
+		{
+			name:          "Renew without a subscription",
+			current:       nil,
+			transitions:   []Transition{Renew(&nextMonth)},
+			expectedError: ErrNotFound,
+		},
+		{
+			name:           "Cancel keeps the paid period",
+			current:        active,
+			transitions:    []Transition{Cancel(nil)},
+			expectedAction: Update,
+			expectedValue:  &Subscription{Plan: "chirpy_red", Status: StatusCanceled, CurrentPeriodEnd: &nextMonth, CanceledAt: &now},
+		},
+		{
+			name:           "Cancel without a known period end",
+			current:        &Subscription{Plan: "chirpy_red", Status: StatusActive},
+			transitions:    []Transition{Cancel(nil)},
+			expectedAction: Update,
+			expectedValue:  &Subscription{Plan: "chirpy_red", Status: StatusCanceled, CurrentPeriodEnd: &now, CanceledAt: &now},
+		},
+		{
+			name:           "Cancel after downgrade",
+			current:        active,
+			transitions:    []Transition{Downgrade(), Cancel(&nextMonth)},
+			expectedAction: Skip,
+			expectedValue:  &Subscription{Plan: "chirpy_red", Status: StatusExpired, CurrentPeriodEnd: &now},
+		},
+		{
+			name:           "Payment failure keeps the period",
+			current:        active,
+			transitions:    []Transition{FailPayment()},
+			expectedAction: Update,
+			expectedValue:  &Subscription{Plan: "chirpy_red", Status: StatusPastDue, CurrentPeriodEnd: &nextMonth},
+		},
+		{
+			name:           "Payment failure after expiry",
+			current:        expired,
+			transitions:    []Transition{FailPayment()},
+			expectedAction: Skip,
+			expectedValue:  expired,
+		},
+		{
+			name:           "Downgrade without a subscription",
+			current:        nil,
+			transitions:    []Transition{Downgrade()},
+			expectedAction: Skip,
+			expectedValue:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current := tt.current
+			var change Change
+			var err error
+			for _, transition := range tt.transitions {
+				change, err = transition(current, now)
+				if err != nil {
+					break
+				}
+				if change.Action != Skip {
+					next := change.Subscription
+					current = &next
+				}
+			}
+
+			if !errors.Is(err, tt.expectedError) {
+				t.Fatalf("transition() error = %v, expectedError %v", err, tt.expectedError)
+			}
+			if err != nil {
+				return
+			}
+			if change.Action != tt.expectedAction {
+				t.Errorf("transition() action expects %v, got %v", tt.expectedAction, change.Action)
+			}
+			if !reflect.DeepEqual(current, tt.expectedValue) {
+				t.Errorf("transition() expects %+v, got %+v", tt.expectedValue, current)
+			}
+		})
+	}
+}
+
+func TestEnded(t *testing.T) {
+	now := time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+	later := now.Add(time.Hour)
+
+	tests := []struct {
+		name          string
+		subscription  Subscription
+		expectedValue bool
+	}{
+		{name: "Active without period end", subscription: Subscription{Status: StatusActive}, expectedValue: false},
+		{name: "Active before period end", subscription: Subscription{Status: StatusActive, CurrentPeriodEnd: &later}, expectedValue: false},
+		{name: "Canceled at period end", subscription: Subscription{Status: StatusCanceled, CurrentPeriodEnd: &now}, expectedValue: true},
+		{name: "Expired", subscription: Subscription{Status: StatusExpired}, expectedValue: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actualValue := Ended(tt.subscription, now)
+			if actualValue != tt.expectedValue {
+				t.Errorf("Ended() expects %v, got %v", tt.expectedValue, actualValue)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package throttle
+
+import "time"
+
+// Policy decides how long to lock out after repeated failed attempts. Once
+// Threshold failures have been counted, each further failure doubles the
+// lockout, starting at BaseDelay and capped at MaxDelay. Failures older than
+// Window are forgotten.
+type Policy struct {
+	Threshold int
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	Window    time.Duration
+}
+
+// LockoutFor returns how long to lock out after failedAttempts consecutive
+// failures, or zero when no lockout applies. A Threshold of zero disables
+// lockouts entirely.
+func (policy Policy) LockoutFor(failedAttempts int) time.Duration {
+	if policy.Threshold <= 0 || failedAttempts < policy.Threshold {
+		return 0
+	}
+
+	delay := policy.BaseDelay
+	for range failedAttempts - policy.Threshold {
+		if delay >= policy.MaxDelay {
+			break
+		}
+		delay *= 2
+	}
+
+	return min(delay, policy.MaxDelay)
+}
+
+// ResetBefore returns the time before which a previous failure no longer
+// counts towards a lockout.
+func (policy Policy) ResetBefore(now time.Time) time.Time {
+	return now.Add(-policy.Window)
+}
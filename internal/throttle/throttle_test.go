@@ -0,0 +1,39 @@
+package throttle
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLockoutFor(t *testing.T) {
+	policy := Policy{
+		Threshold: 3,
+		BaseDelay: time.Minute,
+		MaxDelay:  10 * time.Minute,
+		Window:    time.Hour,
+	}
+
+	tests := []struct {
+		name           string
+		policy         Policy
+		failedAttempts int
+		expectedValue  time.Duration
+	}{
+		{name: "Below threshold", policy: policy, failedAttempts: 2, expectedValue: 0},
+		{name: "At threshold", policy: policy, failedAttempts: 3, expectedValue: time.Minute},
+		{name: "One past threshold", policy: policy, failedAttempts: 4, expectedValue: 2 * time.Minute},
+		{name: "Two past threshold", policy: policy, failedAttempts: 5, expectedValue: 4 * time.Minute},
+		{name: "Capped", policy: policy, failedAttempts: 7, expectedValue: 10 * time.Minute},
+		{name: "Capped far past threshold", policy: policy, failedAttempts: 1000, expectedValue: 10 * time.Minute},
+		{name: "Disabled", policy: Policy{}, failedAttempts: 1000, expectedValue: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actualValue := tt.policy.LockoutFor(tt.failedAttempts)
+			if actualValue != tt.expectedValue {
+				t.Errorf("LockoutFor() expects %v, got %v", tt.expectedValue, actualValue)
+			}
+		})
+	}
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmaeagle99/chirpy/internal/auth"
+	"github.com/jmaeagle99/chirpy/internal/database"
+	"github.com/jmaeagle99/chirpy/internal/throttle"
+)
+
+// loginPolicies hold the lockout policies for failed logins, counted per
+// account and per client IP address, and for password reset requests, counted
+// per email address and per client IP address.
+type loginPolicies struct {
+	account    throttle.Policy
+	ip         throttle.Policy
+	resetEmail throttle.Policy
+	resetIp    throttle.Policy
+}
+
+// loadLoginPolicies reads the lockout thresholds from LOGIN_MAX_ATTEMPTS and
+// LOGIN_MAX_ATTEMPTS_PER_IP, where zero turns the lockout off.
+func loadLoginPolicies() (loginPolicies, error) {
+	accountThreshold, err := envInt("LOGIN_MAX_ATTEMPTS", 5)
+	if err != nil {
+		return loginPolicies{}, err
+	}
+
+	ipThreshold, err := envInt("LOGIN_MAX_ATTEMPTS_PER_IP", 20)
+	if err != nil {
+		return loginPolicies{}, err
+	}
+
+	return loginPolicies{
+		account: throttle.Policy{
+			Threshold: accountThreshold,
+			BaseDelay: time.Minute,
+			MaxDelay:  time.Hour,
+			Window:    24 * time.Hour,
+		},
+		ip: throttle.Policy{
+			Threshold: ipThreshold,
+			BaseDelay: time.Minute,
+			MaxDelay:  time.Hour,
+			Window:    time.Hour,
+		},
+		resetEmail: throttle.Policy{
+			Threshold: 3,
+			BaseDelay: 15 * time.Minute,
+			MaxDelay:  24 * time.Hour,
+			Window:    24 * time.Hour,
+		},
+		resetIp: throttle.Policy{
+			Threshold: 10,
+			BaseDelay: time.Minute,
+			MaxDelay:  time.Hour,
+			Window:    time.Hour,
+		},
+	}, nil
+}
+
+func envInt(name string, defaultValue int) (int, error) {
+	value := os.Getenv(name)
+	if len(value) == 0 {
+		return defaultValue, nil
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative integer", name)
+	}
+
+	return parsed, nil
+}
+
+// loginLockout returns how much longer logins for the subject are locked out,
+// or zero when they are allowed.
+func (cfg *apiConfig) loginLockout(ctx context.Context, scope database.LoginThrottleScope, subject string) (time.Duration, error) {
+	lockedUntil, err := cfg.db.GetLoginLockedUntil(ctx, database.GetLoginLockedUntilParams{
+		Scope:   scope,
+		Subject: subject,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if !lockedUntil.Valid {
+		return 0, nil
+	}
+
+	return max(time.Until(lockedUntil.Time), 0), nil
+}
+
+// recordLoginFailure counts a failed attempt and locks the subject out once
+// the policy says so.
+func (cfg *apiConfig) recordLoginFailure(ctx context.Context, scope database.LoginThrottleScope, subject string, policy throttle.Policy) error {
+	now := time.Now().UTC()
+	failedAttempts, err := cfg.db.RecordLoginFailure(ctx, database.RecordLoginFailureParams{
+		Scope:       scope,
+		Subject:     subject,
+		ResetBefore: policy.ResetBefore(now),
+	})
+	if err != nil {
+		return err
+	}
+
+	lockout := policy.LockoutFor(int(failedAttempts))
+	if lockout == 0 {
+		return nil
+	}
+
+	return cfg.db.LockLogin(ctx, database.LockLoginParams{
+		Scope:       scope,
+		Subject:     subject,
+		LockedUntil: sql.NullTime{Time: now.Add(lockout), Valid: true},
+	})
+}
+
+// purgeStaleLoginThrottles removes the counts that no longer affect any
+// lockout, so the table does not keep a row for every address that ever
+// failed a login.
+func (cfg *apiConfig) purgeStaleLoginThrottles(ctx context.Context) error {
+	now := time.Now().UTC()
+	policies := map[database.LoginThrottleScope]throttle.Policy{
+		database.LoginThrottleScopeAccount:            cfg.loginPolicies.account,
+		database.LoginThrottleScopeIp:                 cfg.loginPolicies.ip,
+		database.LoginThrottleScopePasswordResetEmail: cfg.loginPolicies.resetEmail,
+		database.LoginThrottleScopePasswordResetIp:    cfg.loginPolicies.resetIp,
+	}
+	for scope, policy := range policies {
+		_, err := cfg.db.DeleteStaleLoginThrottles(ctx, database.DeleteStaleLoginThrottlesParams{
+			Scope:       scope,
+			ResetBefore: policy.ResetBefore(now),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// accountSubject identifies an account in the account lockout.
+func accountSubject(userId uuid.UUID) string {
+	return userId.String()
+}
+
+// unknownAccountSubject identifies an email address without an account in the
+// account lockout. Failures for it are counted like those for a real account,
+// so lockouts do not reveal which addresses have accounts.
+func unknownAccountSubject(email string) string {
+	return emailSubject(email)
+}
+
+// emailSubject identifies an email address without storing it.
+func emailSubject(email string) string {
+	return "email:" + auth.HashToken(strings.ToLower(email))
+}
+
+// recordFailedLogin counts a failed attempt against the client address and,
+// when given, against the account subject.
+func (cfg *apiConfig) recordFailedLogin(r *http.Request, account string) error {
+	err := cfg.recordLoginFailure(r.Context(), database.LoginThrottleScopeIp, clientAddress(r), cfg.loginPolicies.ip)
+	if err != nil || len(account) == 0 {
+		return err
+	}
+
+	return cfg.recordLoginFailure(r.Context(), database.LoginThrottleScopeAccount, account, cfg.loginPolicies.account)
+}
+
+func (cfg *apiConfig) writeFailedLogin(w http.ResponseWriter, r *http.Request, account string) {
+	err := cfg.recordFailedLogin(r, account)
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	w.WriteHeader(http.StatusUnauthorized)
+}
+
+func (cfg *apiConfig) clearAccountLockout(ctx context.Context, userId uuid.UUID) (bool, error) {
+	cleared, err := cfg.db.ClearLoginThrottle(ctx, database.ClearLoginThrottleParams{
+		Scope:   database.LoginThrottleScopeAccount,
+		Subject: accountSubject(userId),
+	})
+	return cleared > 0, err
+}
+
+// allowLoginAttempt writes a lockout response and returns false when the
+// client address or, when given, the account subject is locked out.
+func (cfg *apiConfig) allowLoginAttempt(w http.ResponseWriter, r *http.Request, account string) bool {
+	retryAfter, err := cfg.loginLockout(r.Context(), database.LoginThrottleScopeIp, clientAddress(r))
+	if err != nil {
+		writeServerError(w)
+		return false
+	}
+	if retryAfter > 0 {
+		writeLockedOut(w, http.StatusTooManyRequests, retryAfter)
+		return false
+	}
+
+	if len(account) == 0 {
+		return true
+	}
+
+	retryAfter, err = cfg.loginLockout(r.Context(), database.LoginThrottleScopeAccount, account)
+	if err != nil {
+		writeServerError(w)
+		return false
+	}
+	if retryAfter > 0 {
+		writeLockedOut(w, http.StatusLocked, retryAfter)
+		return false
+	}
+
+	return true
+}
+
+// writeLockedOut answers a locked out login: 429 when the client address is
+// throttled, 423 when the account itself is locked.
+func writeLockedOut(w http.ResponseWriter, status int, retryAfter time.Duration) {
+	setRetryAfter(w, retryAfter)
+	writeAsJson(
+		w,
+		ErrorResponse{
+			Error: "too many failed login attempts, try again later",
+		},
+		status)
+}
+
+func setRetryAfter(w http.ResponseWriter, retryAfter time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+}
+
+// clearUserLockout lets an admin unlock an account before its lockout ends.
+func (cfg *apiConfig) clearUserLockout(w http.ResponseWriter, r *http.Request) {
+	userId, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		writeAsJson(
+			w,
+			ErrorResponse{
+				Error: "userId is not valid",
+			},
+			http.StatusBadRequest)
+		return
+	}
+
+	cleared, err := cfg.clearAccountLockout(r.Context(), userId)
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+	if !cleared {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
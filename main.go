@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"io"
 	"log"
@@ -25,28 +26,101 @@ func main() {
 		log.Fatal(err)
 	}
 
+	dbQueries := database.New(db)
+
+	tokenKeys, err := loadTokenKeys()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	mailer, err := loadMailer()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	loginPolicies, err := loadLoginPolicies()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	deletionGracePeriod, err := loadDeletionGracePeriod()
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	apiCfg := apiConfig{
-		db:          database.New(db),
-		platform:    os.Getenv("PLATFORM"),
-		polkaKey:    os.Getenv("POLKA_KEY"),
-		tokenSecret: os.Getenv("TOKEN_SECRET"),
+		bannedWords:         newBannedWordFilter(dbQueries, bannedWordsRefreshInterval),
+		conn:                db,
+		db:                  dbQueries,
+		deletionGracePeriod: deletionGracePeriod,
+		loginPolicies:       loginPolicies,
+		mailer:              mailer,
+		platform:            os.Getenv("PLATFORM"),
+		polkaKey:            os.Getenv("POLKA_KEY"),
+		tokenKeys:           tokenKeys,
+		webhookSecrets:      loadWebhookSecrets(),
+
+		verifiedEmailRequired: os.Getenv("REQUIRE_VERIFIED_EMAIL") == "true",
 	}
 
 	mux := http.NewServeMux()
-	mux.HandleFunc("GET /admin/metrics", apiCfg.getHitsHandler)
-	mux.HandleFunc("POST /admin/reset", apiCfg.resetHitsHandler)
+	mux.Handle("GET /admin/metrics", apiCfg.middlewareRequireRole(database.UserRoleAdmin, http.HandlerFunc(apiCfg.getHitsHandler)))
+	mux.Handle("POST /admin/reset", apiCfg.middlewareRequireRole(database.UserRoleAdmin, http.HandlerFunc(apiCfg.resetHitsHandler)))
+	mux.Handle("PUT /admin/users/{userID}/role", apiCfg.middlewareRequireRole(database.UserRoleAdmin, http.HandlerFunc(apiCfg.updateUserRole)))
+	mux.Handle("DELETE /admin/users/{userID}/lockout", apiCfg.middlewareRequireRole(database.UserRoleAdmin, http.HandlerFunc(apiCfg.clearUserLockout)))
+	mux.Handle("GET /admin/webhook-events", apiCfg.middlewareRequireRole(database.UserRoleAdmin, http.HandlerFunc(apiCfg.getWebhookEvents)))
+	mux.Handle("POST /admin/webhook-events/{eventID}/replay", apiCfg.middlewareRequireRole(database.UserRoleAdmin, http.HandlerFunc(apiCfg.replayWebhookEvent)))
+	mux.Handle("GET /admin/banned-words", apiCfg.middlewareRequireRole(database.UserRoleModerator, http.HandlerFunc(apiCfg.getBannedWords)))
+	mux.Handle("POST /admin/banned-words", apiCfg.middlewareRequireRole(database.UserRoleModerator, http.HandlerFunc(apiCfg.createBannedWord)))
+	mux.Handle("DELETE /admin/banned-words/{wordID}", apiCfg.middlewareRequireRole(database.UserRoleModerator, http.HandlerFunc(apiCfg.deleteBannedWord)))
 	mux.Handle("/app/", apiCfg.middlewareMetricsInc(http.StripPrefix("/app/", http.FileServer(http.Dir(contentRoot)))))
 	mux.HandleFunc("GET /api/healthz", readinessHandler)
+	mux.HandleFunc("GET /.well-known/jwks.json", apiCfg.getJWKS)
 	mux.HandleFunc("POST /api/users", apiCfg.createUser)
 	mux.HandleFunc("PUT /api/users", apiCfg.updateUser)
+	mux.HandleFunc("PATCH /api/users", apiCfg.patchUser)
+	mux.HandleFunc("DELETE /api/users/me", apiCfg.deleteMyAccount)
+	mux.HandleFunc("POST /api/users/me/export", apiCfg.requestDataExport)
+	mux.HandleFunc("GET /api/users/me/export/{exportID}", apiCfg.getDataExport)
+	mux.HandleFunc("POST /api/users/verify", apiCfg.verifyEmail)
+	mux.HandleFunc("POST /api/users/verify/resend", apiCfg.resendEmailVerification)
+	mux.HandleFunc("GET /api/users/{idOrUsername}", apiCfg.getUserProfile)
+	mux.HandleFunc("GET /api/users/me/mentions", apiCfg.getMyMentions)
+	mux.HandleFunc("POST /api/users/me/totp", apiCfg.enrollTOTP)
+	mux.HandleFunc("POST /api/users/me/totp/confirm", apiCfg.confirmTOTP)
+	mux.HandleFunc("DELETE /api/users/me/totp", apiCfg.disableTOTP)
+	mux.HandleFunc("POST /api/users/{userID}/follow", apiCfg.followUser)
+	mux.HandleFunc("DELETE /api/users/{userID}/follow", apiCfg.unfollowUser)
+	mux.HandleFunc("GET /api/users/{userID}/followers", apiCfg.getFollowers)
+	mux.HandleFunc("GET /api/users/{userID}/following", apiCfg.getFollowing)
+	mux.HandleFunc("GET /api/users/{userID}/likes", apiCfg.getUserLikes)
+	mux.HandleFunc("GET /api/timeline", apiCfg.getTimeline)
+	mux.HandleFunc("GET /api/hashtags/trending", apiCfg.getTrendingHashtags)
+	mux.HandleFunc("GET /api/hashtags/{tag}/chirps", apiCfg.getHashtagChirps)
 	mux.HandleFunc("GET /api/chirps", apiCfg.getAllChirps)
 	mux.HandleFunc("POST /api/chirps", apiCfg.createChirp)
+	mux.HandleFunc("GET /api/chirps/search", apiCfg.searchChirps)
 	mux.HandleFunc("DELETE /api/chirps/{chirpID}", apiCfg.deleteChirp)
 	mux.HandleFunc("GET /api/chirps/{chirpID}", apiCfg.getChirp)
+	mux.HandleFunc("PUT /api/chirps/{chirpID}", apiCfg.updateChirp)
+	mux.HandleFunc("GET /api/chirps/{chirpID}/revisions", apiCfg.getChirpRevisions)
+	mux.HandleFunc("GET /api/chirps/{chirpID}/thread", apiCfg.getChirpThread)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/likes", apiCfg.likeChirp)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/likes", apiCfg.unlikeChirp)
 	mux.HandleFunc("POST /api/login", apiCfg.loginUser)
+	mux.HandleFunc("POST /api/login/mfa", apiCfg.loginUserMFA)
 	mux.HandleFunc("POST /api/polka/webhooks", apiCfg.handleWebhook)
 	mux.HandleFunc("POST /api/refresh", apiCfg.getAccessToken)
 	mux.HandleFunc("POST /api/revoke", apiCfg.revokeRefreshToken)
+	mux.HandleFunc("POST /api/password/forgot", apiCfg.forgotPassword)
+	mux.HandleFunc("POST /api/password/reset", apiCfg.resetPassword)
+	mux.HandleFunc("GET /api/sessions", apiCfg.getSessions)
+	mux.HandleFunc("DELETE /api/sessions/{sessionID}", apiCfg.deleteSession)
+	mux.HandleFunc("POST /api/sessions/revoke-all", apiCfg.revokeAllSessions)
+
+	go runEvery(context.Background(), purgeInterval, "purging deleted accounts", apiCfg.purgeDeletedAccounts)
+	go runEvery(context.Background(), purgeInterval, "purging expired data exports", apiCfg.purgeExpiredDataExports)
+	go runEvery(context.Background(), purgeInterval, "purging stale login throttles", apiCfg.purgeStaleLoginThrottles)
 
 	server := http.Server{
 		Handler: mux,
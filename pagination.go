@@ -0,0 +1,172 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// sortOrder is the direction a list is paged in. Cursors record it, since a
+// position only means the same thing when paging in the same direction.
+type sortOrder string
+
+const (
+	sortAsc  sortOrder = "asc"
+	sortDesc sortOrder = "desc"
+)
+
+// pageCursor is the keyset position of the last row on a page. Rank is only
+// set for relevance ordered results such as search.
+type pageCursor struct {
+	Order     sortOrder
+	Rank      sql.NullFloat64
+	CreatedAt time.Time
+	Id        uuid.UUID
+}
+
+type pageRequest struct {
+	Order  sortOrder
+	Cursor *pageCursor
+	Limit  int32
+}
+
+var (
+	errInvalidCursor     = fmt.Errorf("cursor is not valid")
+	errCursorOrderChange = fmt.Errorf("cursor does not match the sort order")
+)
+
+func (cursor pageCursor) encode() string {
+	parts := []string{string(cursor.Order)}
+	if cursor.Rank.Valid {
+		parts = append(parts, strconv.FormatFloat(cursor.Rank.Float64, 'g', -1, 32))
+	}
+	parts = append(parts,
+		cursor.CreatedAt.UTC().Format(time.RFC3339Nano),
+		cursor.Id.String(),
+	)
+	return base64.RawURLEncoding.EncodeToString([]byte(strings.Join(parts, "|")))
+}
+
+func decodeCursor(value string) (pageCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return pageCursor{}, errInvalidCursor
+	}
+
+	parts := strings.Split(string(raw), "|")
+	cursor := pageCursor{
+		Order: sortOrder(parts[0]),
+	}
+	if cursor.Order != sortAsc && cursor.Order != sortDesc {
+		return pageCursor{}, errInvalidCursor
+	}
+
+	parts = parts[1:]
+	switch len(parts) {
+	case 2:
+	case 3:
+		rank, err := strconv.ParseFloat(parts[0], 32)
+		if err != nil {
+			return pageCursor{}, errInvalidCursor
+		}
+		cursor.Rank = sql.NullFloat64{Float64: rank, Valid: true}
+		parts = parts[1:]
+	default:
+		return pageCursor{}, errInvalidCursor
+	}
+
+	cursor.CreatedAt, err = time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return pageCursor{}, errInvalidCursor
+	}
+
+	cursor.Id, err = uuid.Parse(parts[1])
+	if err != nil {
+		return pageCursor{}, errInvalidCursor
+	}
+
+	return cursor, nil
+}
+
+// parsePageRequest reads the page size and cursor for a list paged in the
+// given order. A cursor from a list paged the other way is rejected.
+func parsePageRequest(r *http.Request, order sortOrder) (pageRequest, error) {
+	page := pageRequest{
+		Order: order,
+		Limit: defaultPageLimit,
+	}
+
+	limit_qparam := r.URL.Query().Get("limit")
+	if len(limit_qparam) > 0 {
+		limit, err := strconv.Atoi(limit_qparam)
+		if err != nil || limit < 1 || limit > maxPageLimit {
+			return pageRequest{}, fmt.Errorf("limit must be between 1 and %d", maxPageLimit)
+		}
+		page.Limit = int32(limit)
+	}
+
+	cursor_qparam := r.URL.Query().Get("cursor")
+	if len(cursor_qparam) > 0 {
+		cursor, err := decodeCursor(cursor_qparam)
+		if err != nil {
+			return pageRequest{}, err
+		}
+		if cursor.Order != order {
+			return pageRequest{}, errCursorOrderChange
+		}
+		page.Cursor = &cursor
+	}
+
+	return page, nil
+}
+
+// queryLimit asks the database for one row more than the page size so the
+// handler can tell whether another page follows without a separate count.
+func (page pageRequest) queryLimit() int32 {
+	return page.Limit + 1
+}
+
+func (page pageRequest) cursorRank() sql.NullFloat64 {
+	if page.Cursor == nil {
+		return sql.NullFloat64{}
+	}
+	return page.Cursor.Rank
+}
+
+func (page pageRequest) cursorCreatedAt() sql.NullTime {
+	if page.Cursor == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: page.Cursor.CreatedAt, Valid: true}
+}
+
+func (page pageRequest) cursorId() uuid.NullUUID {
+	if page.Cursor == nil {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: page.Cursor.Id, Valid: true}
+}
+
+// trimPage drops the look-ahead row fetched by queryLimit and returns the
+// cursor pointing at the last row of the page, or "" when no more rows exist.
+func trimPage[T any](page pageRequest, rows []T, key func(T) pageCursor) ([]T, string) {
+	if len(rows) <= int(page.Limit) {
+		return rows, ""
+	}
+
+	rows = rows[:page.Limit]
+	cursor := key(rows[len(rows)-1])
+	cursor.Order = page.Order
+	return rows, cursor.encode()
+}
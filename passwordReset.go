@@ -0,0 +1,232 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/jmaeagle99/chirpy/internal/auth"
+	"github.com/jmaeagle99/chirpy/internal/database"
+	"github.com/jmaeagle99/chirpy/internal/mail"
+)
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+const (
+	passwordResetTokenLifetime = time.Hour
+	mailSendTimeout            = 30 * time.Second
+)
+
+var errResetTokenInvalid = errors.New("reset token is not valid or has expired")
+
+// forgotPassword mails a single-use reset token to the account's address. It
+// answers the same way whether or not the address belongs to an account, and
+// issues the token and sends the mail in the background so response times do
+// not tell either. Requests are limited per client address and per email
+// address, so the endpoint cannot be used to flood a mailbox.
+func (cfg *apiConfig) forgotPassword(w http.ResponseWriter, r *http.Request) {
+	request := ForgotPasswordRequest{}
+
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&request)
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	retryAfter, err := cfg.loginLockout(r.Context(), database.LoginThrottleScopePasswordResetIp, clientAddress(r))
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+	if retryAfter > 0 {
+		setRetryAfter(w, retryAfter)
+		writeAsJson(
+			w,
+			ErrorResponse{
+				Error: "too many password reset requests, try again later",
+			},
+			http.StatusTooManyRequests)
+		return
+	}
+
+	err = cfg.recordLoginFailure(r.Context(), database.LoginThrottleScopePasswordResetIp, clientAddress(r), cfg.loginPolicies.resetIp)
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	// A throttled email address is answered like any other, so the limit
+	// does not reveal whether the address has an account.
+	email := emailSubject(request.Email)
+	retryAfter, err = cfg.loginLockout(r.Context(), database.LoginThrottleScopePasswordResetEmail, email)
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+	if retryAfter > 0 {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	err = cfg.recordLoginFailure(r.Context(), database.LoginThrottleScopePasswordResetEmail, email, cfg.loginPolicies.resetEmail)
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	user, err := cfg.db.GetUserByEmail(r.Context(), request.Email)
+	if errors.Is(err, sql.ErrNoRows) {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	go cfg.sendPasswordReset(user)
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// sendPasswordReset runs outside of any request, so it logs failures.
+func (cfg *apiConfig) sendPasswordReset(user database.User) {
+	ctx, cancel := context.WithTimeout(context.Background(), mailSendTimeout)
+	defer cancel()
+
+	reset_token, err := auth.MakeRefreshToken()
+	if err != nil {
+		log.Printf("issuing password reset token for %s: %v", user.ID, err)
+		return
+	}
+
+	err = cfg.db.CreatePasswordResetToken(ctx, database.CreatePasswordResetTokenParams{
+		TokenHash: auth.HashToken(reset_token),
+		UserID:    user.ID,
+		ExpiresAt: time.Now().UTC().Add(passwordResetTokenLifetime),
+	})
+	if err != nil {
+		log.Printf("issuing password reset token for %s: %v", user.ID, err)
+		return
+	}
+
+	cfg.sendMail(mail.Message{
+		To:      user.Email,
+		Subject: "Reset your Chirpy password",
+		Body: fmt.Sprintf(
+			"Someone asked to reset the password for your Chirpy account.\n\n"+
+				"Reset token: %s\n\n"+
+				"The token expires in %v and can only be used once. "+
+				"If you did not ask for a reset, you can ignore this message.\n",
+			reset_token,
+			passwordResetTokenLifetime),
+	})
+}
+
+// resetPassword sets a new password with a reset token and logs the user out
+// everywhere, since whoever held the old password may hold sessions too. It
+// also lifts any login lockout, as the reset proves control of the address.
+func (cfg *apiConfig) resetPassword(w http.ResponseWriter, r *http.Request) {
+	request := ResetPasswordRequest{}
+
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&request)
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	if len(request.Password) == 0 {
+		writeAsJson(
+			w,
+			ErrorResponse{
+				Error: "password is required",
+			},
+			http.StatusBadRequest)
+		return
+	}
+
+	hashed_password, err := auth.HashPassword(request.Password)
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		userId, err := q.UsePasswordResetToken(r.Context(), auth.HashToken(request.Token))
+		if errors.Is(err, sql.ErrNoRows) {
+			return errResetTokenInvalid
+		}
+		if err != nil {
+			return err
+		}
+
+		err = q.UpdatePassword(r.Context(), database.UpdatePasswordParams{
+			ID:             userId,
+			HashedPassword: hashed_password,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = q.ExpirePasswordResetTokens(r.Context(), userId)
+		if err != nil {
+			return err
+		}
+
+		err = q.RevokeAllUserRefreshTokens(r.Context(), userId)
+		if err != nil {
+			return err
+		}
+
+		_, err = q.ClearLoginThrottle(r.Context(), database.ClearLoginThrottleParams{
+			Scope:   database.LoginThrottleScopeAccount,
+			Subject: accountSubject(userId),
+		})
+		if err != nil {
+			return err
+		}
+
+		return invalidateUserTokens(r.Context(), q, userId)
+	})
+	if errors.Is(err, errResetTokenInvalid) {
+		writeAsJson(
+			w,
+			ErrorResponse{
+				Error: err.Error(),
+			},
+			http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// sendMail delivers a message outside of any request, logging failures
+// because nobody is left to report them to.
+func (cfg *apiConfig) sendMail(message mail.Message) {
+	ctx, cancel := context.WithTimeout(context.Background(), mailSendTimeout)
+	defer cancel()
+
+	err := cfg.mailer.Send(ctx, message)
+	if err != nil {
+		log.Printf("sending mail to %s: %v", message.To, err)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/jmaeagle99/chirpy/internal/chirptext"
+	"github.com/jmaeagle99/chirpy/internal/database"
+)
+
+const (
+	maxDisplayNameLength = 50
+	maxBioLength         = 160
+)
+
+type ProfileResponse struct {
+	Id          uuid.UUID `json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	Username    *string   `json:"username"`
+	DisplayName string    `json:"display_name"`
+	Bio         string    `json:"bio"`
+	ChirpCount  int64     `json:"chirp_count"`
+	IsChirpyRed bool      `json:"is_chirpy_red"`
+}
+
+func validateProfile(username, displayName, bio *string) error {
+	if username != nil {
+		if !chirptext.IsValidHandle(*username) {
+			return fmt.Errorf("username must be 3 to 30 letters, digits or underscores")
+		}
+	}
+
+	if displayName != nil && utf8.RuneCountInString(*displayName) > maxDisplayNameLength {
+		return fmt.Errorf("display_name must be at most %d characters", maxDisplayNameLength)
+	}
+
+	if bio != nil && utf8.RuneCountInString(*bio) > maxBioLength {
+		return fmt.Errorf("bio must be at most %d characters", maxBioLength)
+	}
+
+	return nil
+}
+
+// getUserProfile looks a user up by id or, failing that, by username. The
+// profile is public so it never includes the email address.
+func (cfg *apiConfig) getUserProfile(w http.ResponseWriter, r *http.Request) {
+	idOrUsername := r.PathValue("idOrUsername")
+
+	var user database.User
+	var chirpCount int64
+	var isChirpyRed bool
+	if userId, err := uuid.Parse(idOrUsername); err == nil {
+		profile, err := cfg.db.GetUserProfileById(r.Context(), userId)
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		user, chirpCount, isChirpyRed = profile.User, profile.ChirpCount, profile.IsChirpyRed
+	} else {
+		profile, err := cfg.db.GetUserProfileByUsername(r.Context(), idOrUsername)
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		user, chirpCount, isChirpyRed = profile.User, profile.ChirpCount, profile.IsChirpyRed
+	}
+
+	writeAsJson(
+		w,
+		ProfileResponse{
+			Id:          user.ID,
+			CreatedAt:   user.CreatedAt,
+			Username:    nullStringPtr(user.Username),
+			DisplayName: user.DisplayName,
+			Bio:         user.Bio,
+			ChirpCount:  chirpCount,
+			IsChirpyRed: isChirpyRed,
+		},
+		http.StatusOK)
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/jmaeagle99/chirpy/internal/database"
+)
+
+type UpdateRoleRequest struct {
+	Role string `json:"role"`
+}
+
+// roleRanks orders roles so that each role includes the permissions of the
+// roles ranked below it.
+var roleRanks = map[database.UserRole]int{
+	database.UserRoleUser:      1,
+	database.UserRoleModerator: 2,
+	database.UserRoleAdmin:     3,
+}
+
+func hasRole(role database.UserRole, required database.UserRole) bool {
+	rank, ok := roleRanks[role]
+	return ok && rank >= roleRanks[required]
+}
+
+// middlewareRequireRole only lets requests through when the access token
+// carries a role at least as privileged as the required one. Roles are read
+// from the token, which is why changing a role signs the user out.
+func (cfg *apiConfig) middlewareRequireRole(required database.UserRole, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, err := cfg.validateUserClaims(r)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if !hasRole(database.UserRole(claims.Role), required) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (cfg *apiConfig) updateUserRole(w http.ResponseWriter, r *http.Request) {
+	userId, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		writeAsJson(
+			w,
+			ErrorResponse{
+				Error: "userId is not valid",
+			},
+			http.StatusBadRequest)
+		return
+	}
+
+	request := UpdateRoleRequest{}
+
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&request)
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	role := database.UserRole(request.Role)
+	if _, ok := roleRanks[role]; !ok {
+		writeAsJson(
+			w,
+			ErrorResponse{
+				Error: "role is not valid",
+			},
+			http.StatusBadRequest)
+		return
+	}
+
+	var user database.User
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		user, err = q.UpdateRole(r.Context(), database.UpdateRoleParams{
+			ID:   userId,
+			Role: role,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = q.RevokeAllUserRefreshTokens(r.Context(), userId)
+		if err != nil {
+			return err
+		}
+
+		return invalidateUserTokens(r.Context(), q, userId)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	cfg.writeUser(w, r, user, "", "", http.StatusOK)
+}
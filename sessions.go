@@ -0,0 +1,120 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmaeagle99/chirpy/internal/database"
+)
+
+// A session is a refresh token family: it starts at login and survives every
+// rotation, so the family id is the session id exposed to clients.
+type SessionResponse struct {
+	Id         uuid.UUID `json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	UserAgent  string    `json:"user_agent"`
+	IpAddress  string    `json:"ip_address"`
+}
+
+func (cfg *apiConfig) getSessions(w http.ResponseWriter, r *http.Request) {
+	userId, err := cfg.validateUserAccess(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	sessions, err := cfg.db.ListActiveSessions(r.Context(), userId)
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	writeAsJson(
+		w,
+		Map(sessions, convertSession),
+		http.StatusOK)
+}
+
+func (cfg *apiConfig) deleteSession(w http.ResponseWriter, r *http.Request) {
+	userId, err := cfg.validateUserAccess(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	sessionId, err := uuid.Parse(r.PathValue("sessionID"))
+	if err != nil {
+		writeAsJson(
+			w,
+			ErrorResponse{
+				Error: "sessionId is not valid",
+			},
+			http.StatusBadRequest)
+		return
+	}
+
+	revoked, err := cfg.db.RevokeUserRefreshTokenFamily(r.Context(), database.RevokeUserRefreshTokenFamilyParams{
+		UserID:   userId,
+		FamilyID: sessionId,
+	})
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+	if revoked == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// revokeAllSessions logs the caller out everywhere. Besides revoking every
+// refresh token, it marks all access tokens issued so far as invalid, so
+// stolen access tokens stop working before they expire.
+func (cfg *apiConfig) revokeAllSessions(w http.ResponseWriter, r *http.Request) {
+	userId, err := cfg.validateUserAccess(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		err := q.RevokeAllUserRefreshTokens(r.Context(), userId)
+		if err != nil {
+			return err
+		}
+
+		return invalidateUserTokens(r.Context(), q, userId)
+	})
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// clientAddress returns the IP address of the peer that sent the request.
+func clientAddress(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func convertSession(session database.ListActiveSessionsRow) SessionResponse {
+	return SessionResponse{
+		Id:         session.RefreshToken.FamilyID,
+		CreatedAt:  session.StartedAt,
+		LastUsedAt: session.RefreshToken.CreatedAt,
+		ExpiresAt:  session.RefreshToken.ExpiresAt,
+		UserAgent:  session.RefreshToken.UserAgent,
+		IpAddress:  session.RefreshToken.IpAddress,
+	}
+}
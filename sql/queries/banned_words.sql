@@ -0,0 +1,18 @@
+-- name: CreateBannedWord :one
+INSERT INTO banned_words (
+    word,
+    match_mode
+) VALUES (
+    $1,
+    $2
+)
+RETURNING *;
+
+-- name: DeleteBannedWord :execrows
+DELETE FROM banned_words
+WHERE id = $1;
+
+-- name: GetBannedWords :many
+SELECT *
+FROM banned_words
+ORDER BY banned_words.created_at, banned_words.id;
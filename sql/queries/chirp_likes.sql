@@ -0,0 +1,46 @@
+-- name: LikeChirp :exec
+INSERT INTO chirp_likes (
+    chirp_id,
+    user_id
+) VALUES (
+    $1,
+    $2
+)
+ON CONFLICT DO NOTHING;
+
+-- name: UnlikeChirp :exec
+DELETE FROM chirp_likes
+WHERE
+    chirp_likes.chirp_id = $1 AND
+    chirp_likes.user_id = $2;
+
+-- name: GetLikeCounts :many
+SELECT chirp_likes.chirp_id, count(*) AS like_count
+FROM chirp_likes
+WHERE chirp_likes.chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+GROUP BY chirp_likes.chirp_id;
+
+-- name: GetLikedChirpIds :many
+SELECT chirp_likes.chirp_id
+FROM chirp_likes
+WHERE
+    chirp_likes.user_id = sqlc.arg('user_id') AND
+    chirp_likes.chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[]);
+
+-- name: ListLikedChirps :many
+SELECT sqlc.embed(chirps), chirp_likes.created_at AS liked_at
+FROM chirp_likes
+INNER JOIN chirps
+ON chirps.id = chirp_likes.chirp_id
+WHERE
+    chirp_likes.user_id = sqlc.arg('user_id') AND
+    (sqlc.narg('before_liked_at')::timestamptz IS NULL OR
+        (chirp_likes.created_at, chirp_likes.chirp_id) < (sqlc.narg('before_liked_at'), sqlc.narg('before_id')::uuid))
+ORDER BY chirp_likes.created_at DESC, chirp_likes.chirp_id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: ListChirpLikesByUser :many
+SELECT *
+FROM chirp_likes
+WHERE user_id = $1
+ORDER BY created_at, chirp_id;
@@ -0,0 +1,30 @@
+-- name: MentionUsers :exec
+INSERT INTO chirp_mentions (chirp_id, user_id)
+SELECT sqlc.arg('chirp_id')::uuid, users.id
+FROM users
+WHERE lower(users.username) = ANY(sqlc.arg('handles')::text[])
+ON CONFLICT DO NOTHING;
+
+-- name: ClearChirpMentions :exec
+DELETE FROM chirp_mentions
+WHERE chirp_mentions.chirp_id = $1;
+
+-- name: GetChirpMentions :many
+SELECT chirp_mentions.chirp_id, users.id AS user_id, users.username
+FROM chirp_mentions
+INNER JOIN users
+ON users.id = chirp_mentions.user_id
+WHERE chirp_mentions.chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+ORDER BY chirp_mentions.chirp_id, lower(users.username);
+
+-- name: ListMentioningChirps :many
+SELECT chirps.*
+FROM chirps
+INNER JOIN chirp_mentions
+ON chirp_mentions.chirp_id = chirps.id
+WHERE
+    chirp_mentions.user_id = sqlc.arg('user_id') AND
+    (sqlc.narg('before_created_at')::timestamptz IS NULL OR
+        (chirps.created_at, chirps.id) < (sqlc.narg('before_created_at'), sqlc.narg('before_id')::uuid))
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg('limit');
@@ -0,0 +1,23 @@
+-- name: CreateChirpRevision :one
+INSERT INTO chirp_revisions (
+    chirp_id,
+    body
+) VALUES (
+    $1,
+    $2
+)
+RETURNING *;
+
+-- name: GetChirpRevisions :many
+SELECT *
+FROM chirp_revisions
+WHERE chirp_revisions.chirp_id = $1
+ORDER BY chirp_revisions.created_at, chirp_revisions.id;
+
+-- name: ListChirpRevisionsByUser :many
+SELECT chirp_revisions.*
+FROM chirp_revisions
+INNER JOIN chirps
+ON chirp_revisions.chirp_id = chirps.id
+WHERE chirps.user_id = $1
+ORDER BY chirp_revisions.created_at, chirp_revisions.id;
@@ -1,10 +1,12 @@
 -- name: CreateChirp :one
 INSERT INTO chirps (
     body,
-    user_id
+    user_id,
+    in_reply_to
 ) VALUES (
     $1,
-    $2
+    $2,
+    $3
 )
 RETURNING *;
 
@@ -12,18 +14,106 @@ RETURNING *;
 DELETE FROM chirps
 WHERE id = $1;
 
--- name: GetAllChirps :many
+-- name: ListChirpsAsc :many
 SELECT *
 FROM chirps
-ORDER BY chirps.created_at;
+WHERE
+    (sqlc.narg('author_id')::uuid IS NULL OR chirps.user_id = sqlc.narg('author_id')) AND
+    (sqlc.narg('after_created_at')::timestamptz IS NULL OR
+        (chirps.created_at, chirps.id) > (sqlc.narg('after_created_at'), sqlc.narg('after_id')::uuid))
+ORDER BY chirps.created_at, chirps.id
+LIMIT sqlc.arg('limit');
 
--- name: GetAllChirpsByUser :many
+-- name: ListChirpsDesc :many
 SELECT *
 FROM chirps
-WHERE chirps.user_id = $1
-ORDER BY chirps.created_at;
+WHERE
+    (sqlc.narg('author_id')::uuid IS NULL OR chirps.user_id = sqlc.narg('author_id')) AND
+    (sqlc.narg('before_created_at')::timestamptz IS NULL OR
+        (chirps.created_at, chirps.id) < (sqlc.narg('before_created_at'), sqlc.narg('before_id')::uuid))
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg('limit');
+
+-- The search vector is spelled out as in chirps_body_search_idx so the index
+-- is used.
+-- name: SearchChirps :many
+SELECT sqlc.embed(chirps), ts_rank(to_tsvector('english', chirps.body), query)::real AS rank
+FROM chirps, to_tsquery('english', sqlc.arg('query')) AS query
+WHERE
+    to_tsvector('english', chirps.body) @@ query AND
+    (sqlc.narg('author_id')::uuid IS NULL OR chirps.user_id = sqlc.narg('author_id')) AND
+    (sqlc.narg('before_rank')::real IS NULL OR
+        (ts_rank(to_tsvector('english', chirps.body), query), chirps.created_at, chirps.id) <
+        (sqlc.narg('before_rank'), sqlc.narg('before_created_at')::timestamptz, sqlc.narg('before_id')::uuid))
+ORDER BY rank DESC, chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: ListTimeline :many
+SELECT chirps.*
+FROM chirps
+INNER JOIN follows
+ON chirps.user_id = follows.followee_id
+WHERE
+    follows.follower_id = sqlc.arg('follower_id') AND
+    (sqlc.narg('before_created_at')::timestamptz IS NULL OR
+        (chirps.created_at, chirps.id) < (sqlc.narg('before_created_at'), sqlc.narg('before_id')::uuid))
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: ListChirpReplies :many
+SELECT *
+FROM chirps
+WHERE
+    chirps.in_reply_to = sqlc.arg('parent_id') AND
+    (sqlc.narg('after_created_at')::timestamptz IS NULL OR
+        (chirps.created_at, chirps.id) > (sqlc.narg('after_created_at'), sqlc.narg('after_id')::uuid))
+ORDER BY chirps.created_at, chirps.id
+LIMIT sqlc.arg('limit');
+
+-- name: GetChirpAncestors :many
+WITH RECURSIVE ancestors (id, in_reply_to, depth) AS (
+    SELECT parent.id, parent.in_reply_to, 1
+    FROM chirps AS child
+    INNER JOIN chirps AS parent
+    ON parent.id = child.in_reply_to
+    WHERE child.id = $1
+    UNION ALL
+    SELECT parent.id, parent.in_reply_to, ancestors.depth + 1
+    FROM ancestors
+    INNER JOIN chirps AS parent
+    ON parent.id = ancestors.in_reply_to
+)
+SELECT chirps.*
+FROM chirps
+INNER JOIN ancestors
+ON chirps.id = ancestors.id
+ORDER BY ancestors.depth DESC;
+
+-- name: GetReplyCounts :many
+SELECT chirps.in_reply_to::uuid AS chirp_id, count(*) AS reply_count
+FROM chirps
+WHERE chirps.in_reply_to = ANY(sqlc.arg('chirp_ids')::uuid[])
+GROUP BY chirps.in_reply_to;
 
 -- name: GetChirp :one
 SELECT *
 FROM chirps
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1;
+
+-- name: GetChirpForUpdate :one
+SELECT *
+FROM chirps
+WHERE id = $1
+FOR UPDATE;
+
+-- name: UpdateChirpBody :one
+UPDATE chirps
+SET body = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: ListAllChirpsByUser :many
+SELECT *
+FROM chirps
+WHERE user_id = $1
+ORDER BY created_at, id;
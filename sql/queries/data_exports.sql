@@ -0,0 +1,48 @@
+-- name: CreateDataExport :one
+INSERT INTO data_exports (user_id)
+VALUES ($1)
+RETURNING *;
+
+-- name: GetPendingDataExport :one
+SELECT *
+FROM data_exports
+WHERE
+    user_id = $1 AND
+    status = 'pending';
+
+-- name: GetDataExport :one
+SELECT *
+FROM data_exports
+WHERE
+    id = $1 AND
+    user_id = $2 AND
+    (expires_at IS NULL OR expires_at > now());
+
+-- name: CompleteDataExport :exec
+UPDATE data_exports
+SET
+    status = 'ready',
+    archive = $2,
+    completed_at = now(),
+    expires_at = $3
+WHERE
+    id = $1 AND
+    status = 'pending';
+
+-- name: FailDataExport :exec
+UPDATE data_exports
+SET status = 'failed', completed_at = now(), expires_at = $2
+WHERE id = $1;
+
+-- Exports still pending after their build timeout were abandoned, for
+-- example by a restart, and would otherwise block new exports for the user.
+-- name: FailStaleDataExports :execrows
+UPDATE data_exports
+SET status = 'failed', completed_at = now(), expires_at = sqlc.arg('expires_at')
+WHERE
+    status = 'pending' AND
+    created_at < sqlc.arg('stale_before');
+
+-- name: DeleteExpiredDataExports :execrows
+DELETE FROM data_exports
+WHERE expires_at <= now();
@@ -0,0 +1,28 @@
+-- name: CreateEmailVerificationToken :exec
+INSERT INTO email_verification_tokens (
+    token_hash,
+    user_id,
+    email,
+    expires_at
+) VALUES (
+    $1,
+    $2,
+    $3,
+    $4
+);
+
+-- name: UseEmailVerificationToken :one
+UPDATE email_verification_tokens
+SET used_at = now()
+WHERE
+    token_hash = $1 AND
+    expires_at > now() AND
+    used_at IS NULL
+RETURNING user_id, email;
+
+-- name: ExpireEmailVerificationTokens :exec
+UPDATE email_verification_tokens
+SET used_at = now()
+WHERE
+    user_id = $1 AND
+    used_at IS NULL;
@@ -0,0 +1,43 @@
+-- name: FollowUser :exec
+INSERT INTO follows (
+    follower_id,
+    followee_id
+) VALUES (
+    $1,
+    $2
+)
+ON CONFLICT DO NOTHING;
+
+-- name: UnfollowUser :exec
+DELETE FROM follows
+WHERE
+    follows.follower_id = $1 AND
+    follows.followee_id = $2;
+
+-- name: ListFollowers :many
+SELECT follows.follower_id AS user_id, follows.created_at
+FROM follows
+WHERE
+    follows.followee_id = sqlc.arg('user_id') AND
+    (sqlc.narg('before_created_at')::timestamptz IS NULL OR
+        (follows.created_at, follows.follower_id) < (sqlc.narg('before_created_at'), sqlc.narg('before_id')::uuid))
+ORDER BY follows.created_at DESC, follows.follower_id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: ListFollowing :many
+SELECT follows.followee_id AS user_id, follows.created_at
+FROM follows
+WHERE
+    follows.follower_id = sqlc.arg('user_id') AND
+    (sqlc.narg('before_created_at')::timestamptz IS NULL OR
+        (follows.created_at, follows.followee_id) < (sqlc.narg('before_created_at'), sqlc.narg('before_id')::uuid))
+ORDER BY follows.created_at DESC, follows.followee_id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: ListFollowsByUser :many
+SELECT *
+FROM follows
+WHERE
+    follower_id = $1 OR
+    followee_id = $1
+ORDER BY created_at, follower_id, followee_id;
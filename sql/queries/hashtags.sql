@@ -0,0 +1,50 @@
+-- name: TagChirp :exec
+WITH tags AS (
+    INSERT INTO hashtags (tag)
+    SELECT unnest(sqlc.arg('tags')::text[])
+    ON CONFLICT (tag) DO UPDATE
+    SET tag = EXCLUDED.tag
+    RETURNING id
+)
+INSERT INTO chirp_hashtags (chirp_id, hashtag_id)
+SELECT sqlc.arg('chirp_id')::uuid, tags.id
+FROM tags
+ON CONFLICT DO NOTHING;
+
+-- name: UntagChirp :exec
+DELETE FROM chirp_hashtags
+WHERE chirp_hashtags.chirp_id = $1;
+
+-- name: GetChirpHashtags :many
+SELECT chirp_hashtags.chirp_id, hashtags.tag
+FROM chirp_hashtags
+INNER JOIN hashtags
+ON hashtags.id = chirp_hashtags.hashtag_id
+WHERE chirp_hashtags.chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+ORDER BY chirp_hashtags.chirp_id, hashtags.tag;
+
+-- name: ListHashtagChirps :many
+SELECT chirps.*
+FROM chirps
+INNER JOIN chirp_hashtags
+ON chirp_hashtags.chirp_id = chirps.id
+INNER JOIN hashtags
+ON hashtags.id = chirp_hashtags.hashtag_id
+WHERE
+    hashtags.tag = sqlc.arg('tag') AND
+    (sqlc.narg('before_created_at')::timestamptz IS NULL OR
+        (chirps.created_at, chirps.id) < (sqlc.narg('before_created_at'), sqlc.narg('before_id')::uuid))
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetTrendingHashtags :many
+SELECT hashtags.tag, count(*) AS chirp_count
+FROM chirp_hashtags
+INNER JOIN hashtags
+ON hashtags.id = chirp_hashtags.hashtag_id
+INNER JOIN chirps
+ON chirps.id = chirp_hashtags.chirp_id
+WHERE chirps.created_at >= sqlc.arg('since')
+GROUP BY hashtags.tag
+ORDER BY chirp_count DESC, hashtags.tag
+LIMIT sqlc.arg('limit');
@@ -0,0 +1,49 @@
+-- name: GetLoginLockedUntil :one
+SELECT locked_until
+FROM login_throttles
+WHERE
+    scope = $1 AND
+    subject = $2;
+
+-- name: RecordLoginFailure :one
+INSERT INTO login_throttles (
+    scope,
+    subject,
+    failed_attempts,
+    last_failed_at
+) VALUES (
+    sqlc.arg('scope'),
+    sqlc.arg('subject'),
+    1,
+    now()
+)
+ON CONFLICT (scope, subject) DO UPDATE
+SET
+    failed_attempts = CASE
+        WHEN login_throttles.last_failed_at < sqlc.arg('reset_before') THEN 1
+        ELSE login_throttles.failed_attempts + 1
+    END,
+    last_failed_at = now()
+RETURNING failed_attempts;
+
+-- name: LockLogin :exec
+UPDATE login_throttles
+SET locked_until = $3
+WHERE
+    scope = $1 AND
+    subject = $2;
+
+-- name: ClearLoginThrottle :execrows
+DELETE FROM login_throttles
+WHERE
+    scope = $1 AND
+    subject = $2;
+
+-- Rows whose failures are all older than the policy's window and that are
+-- not locked out count for nothing, so they can go.
+-- name: DeleteStaleLoginThrottles :execrows
+DELETE FROM login_throttles
+WHERE
+    scope = sqlc.arg('scope') AND
+    last_failed_at < sqlc.arg('reset_before') AND
+    (locked_until IS NULL OR locked_until < now());
@@ -0,0 +1,26 @@
+-- name: CreatePasswordResetToken :exec
+INSERT INTO password_reset_tokens (
+    token_hash,
+    user_id,
+    expires_at
+) VALUES (
+    $1,
+    $2,
+    $3
+);
+
+-- name: UsePasswordResetToken :one
+UPDATE password_reset_tokens
+SET used_at = now()
+WHERE
+    token_hash = $1 AND
+    expires_at > now() AND
+    used_at IS NULL
+RETURNING user_id;
+
+-- name: ExpirePasswordResetTokens :exec
+UPDATE password_reset_tokens
+SET used_at = now()
+WHERE
+    user_id = $1 AND
+    used_at IS NULL;
@@ -1,16 +1,82 @@
 -- name: RegisterRefreshToken :one
 INSERT INTO refresh_tokens (
-    token,
+    token_hash,
     user_id,
-    expires_at
+    expires_at,
+    family_id,
+    user_agent,
+    ip_address
 ) VALUES (
     $1,
     $2,
-    $3
+    $3,
+    $4,
+    $5,
+    $6
 )
 RETURNING *;
 
+-- name: GetRefreshTokenForUpdate :one
+SELECT *
+FROM refresh_tokens
+WHERE token_hash = $1
+FOR UPDATE;
+
+-- name: RotateRefreshToken :exec
+UPDATE refresh_tokens
+SET rotated_at = now(), revoked_at = now()
+WHERE token_hash = $1;
+
 -- name: RevokeRefreshToken :exec
 UPDATE refresh_tokens
 SET revoked_at = now()
-WHERE token = $1;
+WHERE
+    family_id = (
+        SELECT presented.family_id
+        FROM refresh_tokens AS presented
+        WHERE presented.token_hash = $1
+    ) AND
+    revoked_at IS NULL;
+
+-- name: RevokeRefreshTokenFamily :exec
+UPDATE refresh_tokens
+SET revoked_at = now()
+WHERE
+    family_id = $1 AND
+    revoked_at IS NULL;
+
+-- name: ListActiveSessions :many
+SELECT
+    sqlc.embed(refresh_tokens),
+    (
+        SELECT min(family.created_at)
+        FROM refresh_tokens AS family
+        WHERE family.family_id = refresh_tokens.family_id
+    )::timestamptz AS started_at
+FROM refresh_tokens
+WHERE
+    refresh_tokens.user_id = $1 AND
+    refresh_tokens.expires_at > now() AND
+    refresh_tokens.revoked_at IS NULL
+ORDER BY refresh_tokens.created_at DESC;
+
+-- name: RevokeUserRefreshTokenFamily :execrows
+UPDATE refresh_tokens
+SET revoked_at = now()
+WHERE
+    user_id = $1 AND
+    family_id = $2 AND
+    revoked_at IS NULL;
+
+-- name: RevokeAllUserRefreshTokens :exec
+UPDATE refresh_tokens
+SET revoked_at = now()
+WHERE
+    user_id = $1 AND
+    revoked_at IS NULL;
+
+-- name: ListRefreshTokensByUser :many
+SELECT *
+FROM refresh_tokens
+WHERE user_id = $1
+ORDER BY created_at;
@@ -0,0 +1,40 @@
+-- name: GetCurrentSubscriptionForUpdate :one
+SELECT *
+FROM subscriptions
+WHERE user_id = $1
+ORDER BY created_at DESC, id DESC
+LIMIT 1
+FOR UPDATE;
+
+-- name: CreateSubscription :one
+INSERT INTO subscriptions (
+    user_id,
+    plan,
+    status,
+    current_period_end
+) VALUES (
+    $1,
+    $2,
+    $3,
+    $4
+)
+RETURNING *;
+
+-- name: UpdateSubscription :one
+UPDATE subscriptions
+SET
+    plan = $2,
+    status = $3,
+    current_period_end = $4,
+    canceled_at = $5
+WHERE id = $1
+RETURNING *;
+
+-- name: IsChirpyRed :one
+SELECT user_is_chirpy_red(sqlc.arg('user_id')::uuid)::boolean AS is_chirpy_red;
+
+-- name: ListSubscriptionsByUser :many
+SELECT *
+FROM subscriptions
+WHERE user_id = $1
+ORDER BY created_at, id;
@@ -1,11 +1,17 @@
 -- name: CreateUser :one
 INSERT INTO users (
     email,
-    hashed_password
+    hashed_password,
+    username,
+    display_name,
+    bio
 )
 VALUES (
     $1,
-    $2
+    $2,
+    $3,
+    $4,
+    $5
 )
 RETURNING *;
 
@@ -17,6 +23,24 @@ SELECT *
 FROM users
 WHERE users.id = $1;
 
+-- name: GetUserProfileById :one
+SELECT sqlc.embed(users), (
+    SELECT count(*)
+    FROM chirps
+    WHERE chirps.user_id = users.id
+) AS chirp_count, user_is_chirpy_red(users.id)::boolean AS is_chirpy_red
+FROM users
+WHERE users.id = $1;
+
+-- name: GetUserProfileByUsername :one
+SELECT sqlc.embed(users), (
+    SELECT count(*)
+    FROM chirps
+    WHERE chirps.user_id = users.id
+) AS chirp_count, user_is_chirpy_red(users.id)::boolean AS is_chirpy_red
+FROM users
+WHERE lower(users.username) = lower(sqlc.arg('username'));
+
 -- name: GetUserByEmail :one
 SELECT *
 FROM users
@@ -28,19 +52,90 @@ FROM users
 INNER JOIN refresh_tokens
 ON users.id = refresh_tokens.user_id
 WHERE
-    refresh_tokens.token = $1 AND
+    refresh_tokens.token_hash = $1 AND
     refresh_tokens.expires_at > now() AND
     refresh_tokens.revoked_at IS NULL
 ;
 
--- name: UpdateEmailAndPassword :one
+-- name: UpdateProfile :one
+UPDATE users
+SET
+    username = coalesce(sqlc.narg('username'), username),
+    display_name = coalesce(sqlc.narg('display_name'), display_name),
+    bio = coalesce(sqlc.narg('bio'), bio)
+WHERE id = sqlc.arg('id')
+RETURNING *;
+
+-- name: UpdateRole :one
+UPDATE users
+SET role = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: InvalidateUserTokens :exec
+UPDATE users
+SET tokens_invalid_before = $2
+WHERE id = $1;
+
+-- name: GetUserTokensInvalidBefore :one
+SELECT tokens_invalid_before
+FROM users
+WHERE id = $1;
+
+-- name: SetPendingTOTPSecret :execrows
+UPDATE users
+SET totp_secret = $2, totp_last_step = NULL
+WHERE
+    id = $1 AND
+    totp_enabled_at IS NULL;
+
+-- name: EnableTOTP :execrows
+UPDATE users
+SET totp_enabled_at = now(), totp_last_step = $2
+WHERE
+    id = $1 AND
+    totp_secret IS NOT NULL AND
+    totp_enabled_at IS NULL;
+
+-- name: DisableTOTP :exec
 UPDATE users
-SET email = $2, hashed_password = $3
+SET totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = NULL
+WHERE id = $1;
+
+-- Each time step is accepted at most once, so a code seen by an attacker
+-- cannot be replayed within its validity window.
+-- name: RecordTOTPStep :execrows
+UPDATE users
+SET totp_last_step = $2
+WHERE
+    id = $1 AND
+    (totp_last_step IS NULL OR totp_last_step < $2);
+
+-- name: UpdatePassword :exec
+UPDATE users
+SET hashed_password = $2
+WHERE id = $1;
+
+-- name: VerifyEmail :one
+UPDATE users
+SET email = $2, email_verified_at = now()
 WHERE id = $1
 RETURNING *;
 
--- name: UpgradeToRed :one
+-- name: ScheduleUserDeletion :one
 UPDATE users
-SET is_chirpy_red = true
+SET delete_after = $2
 WHERE id = $1
-RETURNING *;
\ No newline at end of file
+RETURNING *;
+
+-- name: CancelUserDeletion :exec
+UPDATE users
+SET delete_after = NULL
+WHERE
+    id = $1 AND
+    delete_after IS NOT NULL;
+
+-- name: PurgeDeletedUsers :many
+DELETE FROM users
+WHERE delete_after <= now()
+RETURNING id;
@@ -0,0 +1,62 @@
+-- Claims an event for processing. A new event is inserted; a failed one, or
+-- one whose processing was abandoned before stale_before, is claimed again
+-- for a retry. Events that were already handled, or are being handled right
+-- now, return no row.
+-- name: ClaimWebhookEvent :one
+INSERT INTO webhook_events (
+    event_id,
+    event_type,
+    payload
+) VALUES (
+    $1,
+    $2,
+    $3
+)
+ON CONFLICT (event_id) DO UPDATE
+SET
+    status = 'received',
+    attempts = webhook_events.attempts + 1,
+    error = NULL,
+    claimed_at = now(),
+    processed_at = NULL
+WHERE
+    webhook_events.status = 'failed' OR
+    (webhook_events.status = 'received' AND webhook_events.claimed_at < sqlc.arg('stale_before'))
+RETURNING *;
+
+-- name: ReclaimWebhookEvent :one
+UPDATE webhook_events
+SET
+    status = 'received',
+    attempts = attempts + 1,
+    error = NULL,
+    claimed_at = now(),
+    processed_at = NULL
+WHERE
+    id = $1 AND
+    (status = 'failed' OR (status = 'received' AND claimed_at < sqlc.arg('stale_before')))
+RETURNING *;
+
+-- name: FinishWebhookEvent :one
+UPDATE webhook_events
+SET
+    status = $2,
+    error = $3,
+    processed_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: GetWebhookEvent :one
+SELECT *
+FROM webhook_events
+WHERE id = $1;
+
+-- name: ListWebhookEvents :many
+SELECT *
+FROM webhook_events
+WHERE
+    (sqlc.narg('status')::webhook_event_status IS NULL OR webhook_events.status = sqlc.narg('status')) AND
+    (sqlc.narg('before_received_at')::timestamptz IS NULL OR
+        (webhook_events.received_at, webhook_events.id) < (sqlc.narg('before_received_at'), sqlc.narg('before_id')::uuid))
+ORDER BY webhook_events.received_at DESC, webhook_events.id DESC
+LIMIT sqlc.arg('limit');
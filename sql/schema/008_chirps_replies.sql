@@ -0,0 +1,15 @@
+-- +goose Up
+-- Replies keep the id of the chirp they answered after it is deleted, so a
+-- thread shows where a deleted chirp used to be instead of turning its
+-- replies into top-level chirps. New replies are checked against existing
+-- chirps when they are created.
+ALTER TABLE chirps
+ADD COLUMN in_reply_to UUID;
+
+CREATE INDEX chirps_in_reply_to_created_at_id_idx ON chirps (in_reply_to, created_at, id);
+
+-- +goose Down
+DROP INDEX IF EXISTS chirps_in_reply_to_created_at_id_idx;
+
+ALTER TABLE chirps
+DROP COLUMN in_reply_to;
@@ -0,0 +1,7 @@
+-- +goose Up
+-- Search uses an expression index instead of a stored column, so the search
+-- vector is not returned with every chirp that is read.
+CREATE INDEX chirps_body_search_idx ON chirps USING GIN (to_tsvector('english', body));
+
+-- +goose Down
+DROP INDEX IF EXISTS chirps_body_search_idx;
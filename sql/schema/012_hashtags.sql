@@ -0,0 +1,34 @@
+-- +goose Up
+CREATE TABLE hashtags (
+    id UUID NOT NULL DEFAULT gen_random_uuid() PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    tag TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE chirp_hashtags (
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    hashtag_id UUID NOT NULL REFERENCES hashtags(id) ON DELETE CASCADE,
+    PRIMARY KEY (chirp_id, hashtag_id)
+);
+
+CREATE INDEX chirp_hashtags_hashtag_id_idx ON chirp_hashtags (hashtag_id);
+
+-- Tag existing chirps using the same rules as chirptext.ExtractHashtags.
+INSERT INTO hashtags (tag)
+SELECT DISTINCT lower(matches.parts[1])
+FROM chirps
+CROSS JOIN LATERAL regexp_matches(chirps.body, '(?:^|[^[:alnum:]_&/#])#([[:alnum:]_]+)', 'g') AS matches(parts)
+WHERE matches.parts[1] ~ '[[:alpha:]]'
+ON CONFLICT (tag) DO NOTHING;
+
+INSERT INTO chirp_hashtags (chirp_id, hashtag_id)
+SELECT DISTINCT chirps.id, hashtags.id
+FROM chirps
+CROSS JOIN LATERAL regexp_matches(chirps.body, '(?:^|[^[:alnum:]_&/#])#([[:alnum:]_]+)', 'g') AS matches(parts)
+INNER JOIN hashtags
+ON hashtags.tag = lower(matches.parts[1])
+ON CONFLICT DO NOTHING;
+
+-- +goose Down
+DROP TABLE IF EXISTS chirp_hashtags;
+DROP TABLE IF EXISTS hashtags;
@@ -0,0 +1,19 @@
+-- +goose Up
+ALTER TABLE users
+RENAME COLUMN handle TO username;
+
+ALTER INDEX users_handle_lower_idx RENAME TO users_username_lower_idx;
+
+ALTER TABLE users
+ADD COLUMN display_name TEXT NOT NULL DEFAULT '',
+ADD COLUMN bio TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE users
+DROP COLUMN bio,
+DROP COLUMN display_name;
+
+ALTER INDEX users_username_lower_idx RENAME TO users_handle_lower_idx;
+
+ALTER TABLE users
+RENAME COLUMN username TO handle;
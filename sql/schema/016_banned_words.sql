@@ -0,0 +1,20 @@
+-- +goose Up
+CREATE TYPE banned_word_match_mode AS ENUM ('whole_word', 'substring', 'regex');
+
+CREATE TABLE banned_words (
+    id UUID NOT NULL DEFAULT gen_random_uuid() PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    word TEXT NOT NULL,
+    match_mode banned_word_match_mode NOT NULL DEFAULT 'whole_word',
+    UNIQUE (word, match_mode)
+);
+
+INSERT INTO banned_words (word, match_mode)
+VALUES
+    ('kerfuffle', 'whole_word'),
+    ('sharbert', 'whole_word'),
+    ('fornax', 'whole_word');
+
+-- +goose Down
+DROP TABLE IF EXISTS banned_words;
+DROP TYPE IF EXISTS banned_word_match_mode;
@@ -0,0 +1,16 @@
+-- +goose Up
+ALTER TABLE refresh_tokens
+RENAME COLUMN token TO token_hash;
+
+-- Existing tokens keep working: clients still hold the raw token, and its
+-- SHA-256 digest is what lookups compare against from now on.
+UPDATE refresh_tokens
+SET token_hash = encode(digest(token_hash, 'sha256'), 'hex');
+
+-- +goose Down
+-- Raw tokens cannot be recovered from their digests, so every session is
+-- revoked by removing it.
+DELETE FROM refresh_tokens;
+
+ALTER TABLE refresh_tokens
+RENAME COLUMN token_hash TO token;
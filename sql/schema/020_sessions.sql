@@ -0,0 +1,19 @@
+-- +goose Up
+ALTER TABLE refresh_tokens
+ADD COLUMN user_agent TEXT NOT NULL DEFAULT '',
+ADD COLUMN ip_address TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX refresh_tokens_user_id_idx ON refresh_tokens (user_id);
+
+ALTER TABLE users
+ADD COLUMN tokens_invalid_before TIMESTAMPTZ;
+
+-- +goose Down
+ALTER TABLE users
+DROP COLUMN tokens_invalid_before;
+
+DROP INDEX IF EXISTS refresh_tokens_user_id_idx;
+
+ALTER TABLE refresh_tokens
+DROP COLUMN ip_address,
+DROP COLUMN user_agent;
@@ -0,0 +1,28 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN email_verified_at TIMESTAMPTZ;
+
+-- Accounts from before verification existed are trusted as they are, so
+-- REQUIRE_VERIFIED_EMAIL does not lock them out.
+UPDATE users
+SET email_verified_at = created_at;
+
+-- Each token confirms one address for one user: the signup address, or the
+-- new address of a pending email change, which is only applied once the
+-- token is used.
+CREATE TABLE email_verification_tokens (
+    token_hash TEXT NOT NULL PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    email TEXT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ
+);
+
+CREATE INDEX email_verification_tokens_user_id_idx ON email_verification_tokens (user_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS email_verification_tokens;
+
+ALTER TABLE users
+DROP COLUMN email_verified_at;
@@ -0,0 +1,19 @@
+-- +goose Up
+CREATE TYPE login_throttle_scope AS ENUM ('account', 'ip');
+
+-- Failed logins are counted per account (by user id) and per client IP
+-- address, so neither guessing one account's password from many addresses
+-- nor many accounts' passwords from one address goes unchecked.
+CREATE TABLE login_throttles (
+    scope login_throttle_scope NOT NULL,
+    subject TEXT NOT NULL,
+    failed_attempts INTEGER NOT NULL,
+    last_failed_at TIMESTAMPTZ NOT NULL,
+    locked_until TIMESTAMPTZ,
+    PRIMARY KEY (scope, subject)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS login_throttles;
+
+DROP TYPE IF EXISTS login_throttle_scope;
@@ -0,0 +1,23 @@
+-- +goose Up
+CREATE TYPE data_export_status AS ENUM ('pending', 'ready', 'failed');
+
+CREATE TABLE data_exports (
+    id UUID NOT NULL DEFAULT gen_random_uuid() PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status data_export_status NOT NULL DEFAULT 'pending',
+    archive BYTEA,
+    completed_at TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ
+);
+
+CREATE INDEX data_exports_user_id_idx ON data_exports (user_id);
+
+-- At most one export per user is being built at a time.
+CREATE UNIQUE INDEX data_exports_pending_user_id_idx ON data_exports (user_id)
+WHERE status = 'pending';
+
+-- +goose Down
+DROP TABLE IF EXISTS data_exports;
+
+DROP TYPE IF EXISTS data_export_status;
@@ -0,0 +1,24 @@
+-- +goose Up
+CREATE TYPE webhook_event_status AS ENUM ('received', 'processed', 'ignored', 'failed');
+
+-- Every webhook Polka delivers is kept, keyed by its event id, so retries of
+-- an event that was already handled are skipped and failed events can be
+-- replayed.
+CREATE TABLE webhook_events (
+    id UUID NOT NULL DEFAULT gen_random_uuid() PRIMARY KEY,
+    event_id TEXT NOT NULL UNIQUE,
+    event_type TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    received_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    status webhook_event_status NOT NULL DEFAULT 'received',
+    attempts INTEGER NOT NULL DEFAULT 1,
+    error TEXT,
+    processed_at TIMESTAMPTZ
+);
+
+CREATE INDEX webhook_events_status_received_at_idx ON webhook_events (status, received_at, id);
+
+-- +goose Down
+DROP TABLE IF EXISTS webhook_events;
+
+DROP TYPE IF EXISTS webhook_event_status;
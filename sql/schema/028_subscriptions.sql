@@ -0,0 +1,61 @@
+-- +goose Up
+CREATE TYPE subscription_status AS ENUM ('active', 'past_due', 'canceled', 'expired');
+
+-- A user's subscriptions over time; the most recent one is current. A
+-- current_period_end of NULL means the period has no known end, as for
+-- upgrades Polka sent before it reported billing periods.
+CREATE TABLE subscriptions (
+    id UUID NOT NULL DEFAULT gen_random_uuid() PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    plan TEXT NOT NULL,
+    status subscription_status NOT NULL,
+    current_period_end TIMESTAMPTZ,
+    canceled_at TIMESTAMPTZ
+);
+
+CREATE INDEX subscriptions_user_id_created_at_idx ON subscriptions (user_id, created_at, id);
+
+CREATE TRIGGER subscriptions_set_updated_at
+BEFORE UPDATE ON subscriptions
+FOR EACH ROW
+EXECUTE FUNCTION set_updated_at();
+
+-- Only the current subscription counts. Chirpy Red lasts until the end of
+-- the paid period: canceling or a failed payment does not take it away
+-- early, only the period running out or a downgrade does.
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION user_is_chirpy_red(subscriber UUID)
+RETURNS BOOLEAN AS $$
+  SELECT COALESCE((
+    SELECT
+      subscriptions.status <> 'expired' AND
+      (subscriptions.current_period_end IS NULL OR subscriptions.current_period_end > now())
+    FROM subscriptions
+    WHERE subscriptions.user_id = subscriber
+    ORDER BY subscriptions.created_at DESC, subscriptions.id DESC
+    LIMIT 1
+  ), false);
+$$ LANGUAGE sql STABLE;
+-- +goose StatementEnd
+
+INSERT INTO subscriptions (user_id, plan, status)
+SELECT id, 'chirpy_red', 'active'
+FROM users
+WHERE is_chirpy_red;
+
+ALTER TABLE users
+DROP COLUMN is_chirpy_red;
+
+-- +goose Down
+ALTER TABLE users
+ADD COLUMN is_chirpy_red BOOLEAN NOT NULL DEFAULT false;
+
+UPDATE users
+SET is_chirpy_red = user_is_chirpy_red(users.id);
+
+DROP FUNCTION IF EXISTS user_is_chirpy_red(UUID);
+DROP TRIGGER IF EXISTS subscriptions_set_updated_at ON subscriptions;
+DROP TABLE IF EXISTS subscriptions;
+DROP TYPE IF EXISTS subscription_status;
@@ -0,0 +1,13 @@
+-- +goose Up
+-- When processing of an event last started. An event still marked received
+-- long after that was abandoned, for example by a crash, and can be claimed
+-- again.
+ALTER TABLE webhook_events
+ADD COLUMN claimed_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+UPDATE webhook_events
+SET claimed_at = received_at;
+
+-- +goose Down
+ALTER TABLE webhook_events
+DROP COLUMN claimed_at;
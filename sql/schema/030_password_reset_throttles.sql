@@ -0,0 +1,19 @@
+-- +goose Up
+-- Password reset requests are counted per email address and per client IP
+-- address alongside failed logins.
+ALTER TYPE login_throttle_scope ADD VALUE 'password_reset_email';
+
+ALTER TYPE login_throttle_scope ADD VALUE 'password_reset_ip';
+
+-- +goose Down
+DELETE FROM login_throttles
+WHERE scope IN ('password_reset_email', 'password_reset_ip');
+
+ALTER TYPE login_throttle_scope RENAME TO login_throttle_scope_old;
+
+CREATE TYPE login_throttle_scope AS ENUM ('account', 'ip');
+
+ALTER TABLE login_throttles
+ALTER COLUMN scope TYPE login_throttle_scope USING scope::TEXT::login_throttle_scope;
+
+DROP TYPE login_throttle_scope_old;
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmaeagle99/chirpy/internal/database"
+	"github.com/jmaeagle99/chirpy/internal/subscription"
+)
+
+var errWebhookUserNotFound = errors.New("user not found")
+
+// updateSubscription applies transition to the user's latest subscription
+// while holding a lock on it, so deliveries for the same user cannot
+// interleave.
+func (cfg *apiConfig) updateSubscription(ctx context.Context, userId uuid.UUID, transition subscription.Transition) error {
+	return cfg.withTx(ctx, func(q *database.Queries) error {
+		_, err := q.GetUserById(ctx, userId)
+		if errors.Is(err, sql.ErrNoRows) {
+			return errWebhookUserNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		var current *subscription.Subscription
+		row, err := q.GetCurrentSubscriptionForUpdate(ctx, userId)
+		if err == nil {
+			current = &subscription.Subscription{
+				Plan:             row.Plan,
+				Status:           subscription.Status(row.Status),
+				CurrentPeriodEnd: nullTimePtr(row.CurrentPeriodEnd),
+				CanceledAt:       nullTimePtr(row.CanceledAt),
+			}
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		change, err := transition(current, time.Now().UTC())
+		if err != nil {
+			return err
+		}
+
+		switch change.Action {
+		case subscription.Update:
+			_, err = q.UpdateSubscription(ctx, database.UpdateSubscriptionParams{
+				ID:               row.ID,
+				Plan:             change.Subscription.Plan,
+				Status:           database.SubscriptionStatus(change.Subscription.Status),
+				CurrentPeriodEnd: nullTimeFromPtr(change.Subscription.CurrentPeriodEnd),
+				CanceledAt:       nullTimeFromPtr(change.Subscription.CanceledAt),
+			})
+		case subscription.Create:
+			_, err = q.CreateSubscription(ctx, database.CreateSubscriptionParams{
+				UserID:           userId,
+				Plan:             change.Subscription.Plan,
+				Status:           database.SubscriptionStatus(change.Subscription.Status),
+				CurrentPeriodEnd: nullTimeFromPtr(change.Subscription.CurrentPeriodEnd),
+			})
+		}
+		return err
+	})
+}
+
+func (cfg *apiConfig) upgradeUserRed(ctx context.Context, eventData UserUpgradedEventData) error {
+	return cfg.updateSubscription(ctx, eventData.UserId, subscription.Upgrade(eventData.Plan, eventData.CurrentPeriodEnd))
+}
+
+func (cfg *apiConfig) renewSubscription(ctx context.Context, eventData SubscriptionRenewedEventData) error {
+	return cfg.updateSubscription(ctx, eventData.UserId, subscription.Renew(eventData.CurrentPeriodEnd))
+}
+
+func (cfg *apiConfig) cancelSubscription(ctx context.Context, eventData SubscriptionCanceledEventData) error {
+	return cfg.updateSubscription(ctx, eventData.UserId, subscription.Cancel(eventData.CurrentPeriodEnd))
+}
+
+func (cfg *apiConfig) failSubscriptionPayment(ctx context.Context, eventData PaymentFailedEventData) error {
+	return cfg.updateSubscription(ctx, eventData.UserId, subscription.FailPayment())
+}
+
+func (cfg *apiConfig) downgradeUserRed(ctx context.Context, eventData UserDowngradedEventData) error {
+	return cfg.updateSubscription(ctx, eventData.UserId, subscription.Downgrade())
+}
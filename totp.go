@@ -0,0 +1,296 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmaeagle99/chirpy/internal/auth"
+	"github.com/jmaeagle99/chirpy/internal/database"
+)
+
+type TOTPEnrollmentResponse struct {
+	Secret     string `json:"secret"`
+	OtpauthUri string `json:"otpauth_uri"`
+}
+
+type TOTPCodeRequest struct {
+	Code string `json:"code"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+const (
+	totpIssuer         = "Chirpy"
+	recoveryCodeCount  = 10
+	mfaChallengeExpiry = 5 * time.Minute
+)
+
+var errTOTPAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+
+// enrollTOTP starts enrollment by generating a new secret. The secret stays
+// pending, and login keeps working without it, until a code generated from
+// it is confirmed.
+func (cfg *apiConfig) enrollTOTP(w http.ResponseWriter, r *http.Request) {
+	userId, err := cfg.validateUserAccess(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	user, err := cfg.db.GetUserById(r.Context(), userId)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	updated, err := cfg.db.SetPendingTOTPSecret(r.Context(), database.SetPendingTOTPSecretParams{
+		ID:         userId,
+		TotpSecret: sql.NullString{String: secret, Valid: true},
+	})
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+	if updated == 0 {
+		writeTOTPAlreadyEnabled(w)
+		return
+	}
+
+	writeAsJson(
+		w,
+		TOTPEnrollmentResponse{
+			Secret:     secret,
+			OtpauthUri: auth.TOTPURI(secret, totpIssuer, user.Email),
+		},
+		http.StatusOK)
+}
+
+// confirmTOTP enables two-factor authentication once the caller proves their
+// authenticator app produces codes for the pending secret, and hands out the
+// recovery codes. They are only shown here; the database keeps digests.
+func (cfg *apiConfig) confirmTOTP(w http.ResponseWriter, r *http.Request) {
+	userId, err := cfg.validateUserAccess(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	request := TOTPCodeRequest{}
+
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&request)
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	user, err := cfg.db.GetUserById(r.Context(), userId)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	if user.TotpEnabledAt.Valid {
+		writeTOTPAlreadyEnabled(w)
+		return
+	}
+
+	if !user.TotpSecret.Valid {
+		writeAsJson(
+			w,
+			ErrorResponse{
+				Error: "two-factor authentication enrollment has not been started",
+			},
+			http.StatusBadRequest)
+		return
+	}
+
+	step, ok, err := auth.ValidateTOTP(user.TotpSecret.String, request.Code, time.Now())
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+	if !ok {
+		writeInvalidCode(w)
+		return
+	}
+
+	recovery_codes, err := auth.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		enabled, err := q.EnableTOTP(r.Context(), database.EnableTOTPParams{
+			ID:           userId,
+			TotpLastStep: sql.NullInt64{Int64: step, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		if enabled == 0 {
+			return errTOTPAlreadyEnabled
+		}
+
+		return replaceRecoveryCodes(r.Context(), q, userId, recovery_codes)
+	})
+	if errors.Is(err, errTOTPAlreadyEnabled) {
+		writeTOTPAlreadyEnabled(w)
+		return
+	}
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	writeAsJson(
+		w,
+		RecoveryCodesResponse{
+			RecoveryCodes: recovery_codes,
+		},
+		http.StatusOK)
+}
+
+// disableTOTP turns two-factor authentication off. It takes a current code or
+// a recovery code so a stolen access token alone cannot remove the second
+// factor. Wrong codes count towards the account lockout like failed logins, so
+// the codes cannot be guessed here instead.
+func (cfg *apiConfig) disableTOTP(w http.ResponseWriter, r *http.Request) {
+	userId, err := cfg.validateUserAccess(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	request := TOTPCodeRequest{}
+
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&request)
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	user, err := cfg.db.GetUserById(r.Context(), userId)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	if !user.TotpEnabledAt.Valid {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if !cfg.allowLoginAttempt(w, r, accountSubject(userId)) {
+		return
+	}
+
+	ok, err := verifySecondFactor(r.Context(), cfg.db, user, request.Code)
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+	if !ok {
+		err = cfg.recordFailedLogin(r, accountSubject(userId))
+		if err != nil {
+			writeServerError(w)
+			return
+		}
+
+		writeInvalidCode(w)
+		return
+	}
+
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		err := q.DisableTOTP(r.Context(), userId)
+		if err != nil {
+			return err
+		}
+
+		return q.DeleteRecoveryCodes(r.Context(), userId)
+	})
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// verifySecondFactor accepts either a TOTP code, which is only accepted once
+// per time step, or an unused recovery code, which is used up.
+func verifySecondFactor(ctx context.Context, q *database.Queries, user database.User, code string) (bool, error) {
+	if !user.TotpSecret.Valid {
+		return false, nil
+	}
+
+	step, ok, err := auth.ValidateTOTP(user.TotpSecret.String, code, time.Now())
+	if err != nil {
+		return false, err
+	}
+	if ok {
+		recorded, err := q.RecordTOTPStep(ctx, database.RecordTOTPStepParams{
+			ID:           user.ID,
+			TotpLastStep: sql.NullInt64{Int64: step, Valid: true},
+		})
+		return recorded > 0, err
+	}
+
+	used, err := q.UseRecoveryCode(ctx, database.UseRecoveryCodeParams{
+		UserID:   user.ID,
+		CodeHash: auth.HashRecoveryCode(code),
+	})
+	return used > 0, err
+}
+
+func replaceRecoveryCodes(ctx context.Context, q *database.Queries, userId uuid.UUID, codes []string) error {
+	err := q.DeleteRecoveryCodes(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	for _, code := range codes {
+		err = q.CreateRecoveryCode(ctx, database.CreateRecoveryCodeParams{
+			UserID:   userId,
+			CodeHash: auth.HashRecoveryCode(code),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeTOTPAlreadyEnabled(w http.ResponseWriter) {
+	writeAsJson(
+		w,
+		ErrorResponse{
+			Error: errTOTPAlreadyEnabled.Error(),
+		},
+		http.StatusConflict)
+}
+
+func writeInvalidCode(w http.ResponseWriter) {
+	writeAsJson(
+		w,
+		ErrorResponse{
+			Error: "code is not valid",
+		},
+		http.StatusBadRequest)
+}
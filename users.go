@@ -1,23 +1,43 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmaeagle99/chirpy/internal/auth"
 	"github.com/jmaeagle99/chirpy/internal/database"
+	"github.com/lib/pq"
 )
 
 type CreateUserRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email       string  `json:"email"`
+	Password    string  `json:"password"`
+	Username    *string `json:"username"`
+	DisplayName *string `json:"display_name"`
+	Bio         *string `json:"bio"`
 }
 
 type UpdateUserRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email       string  `json:"email"`
+	Password    string  `json:"password"`
+	Username    *string `json:"username"`
+	DisplayName *string `json:"display_name"`
+	Bio         *string `json:"bio"`
+}
+
+// PatchUserRequest changes only the fields that are present.
+type PatchUserRequest struct {
+	Email           *string `json:"email"`
+	Password        *string `json:"password"`
+	CurrentPassword *string `json:"current_password"`
+	Username        *string `json:"username"`
+	DisplayName     *string `json:"display_name"`
+	Bio             *string `json:"bio"`
 }
 
 type LoginUserRequest struct {
@@ -25,20 +45,38 @@ type LoginUserRequest struct {
 	Password string `json:"password"`
 }
 
+type MFALoginRequest struct {
+	MfaToken string `json:"mfa_token"`
+	Code     string `json:"code"`
+}
+
+type MFAChallengeResponse struct {
+	MfaRequired bool   `json:"mfa_required"`
+	MfaToken    string `json:"mfa_token"`
+}
+
 type UserResponse struct {
-	Id           uuid.UUID `json:"id"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
-	Email        string    `json:"email"`
-	IsChirpyRed  bool      `json:"is_chirpy_red"`
-	Token        string    `json:"token,omitempty"`
-	RefreshToken string    `json:"refresh_token,omitempty"`
+	Id            uuid.UUID `json:"id"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	Email         string    `json:"email"`
+	EmailVerified bool      `json:"email_verified"`
+	Username      *string   `json:"username"`
+	DisplayName   string    `json:"display_name"`
+	Bio           string    `json:"bio"`
+	Role          string    `json:"role"`
+	IsChirpyRed   bool      `json:"is_chirpy_red"`
+	Token         string    `json:"token,omitempty"`
+	RefreshToken  string    `json:"refresh_token,omitempty"`
 }
 
 type AccessTokenResponse struct {
-	Token string `json:"token"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
 }
 
+const refreshTokenLifetime = 60 * 24 * time.Hour
+
 func (cfg *apiConfig) createUser(w http.ResponseWriter, r *http.Request) {
 	request := CreateUserRequest{}
 
@@ -49,25 +87,52 @@ func (cfg *apiConfig) createUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	err = validateProfile(request.Username, request.DisplayName, request.Bio)
+	if err != nil {
+		writeAsJson(
+			w,
+			ErrorResponse{
+				Error: err.Error(),
+			},
+			http.StatusBadRequest)
+		return
+	}
+
 	hashed_password, err := auth.HashPassword(request.Password)
 	if err != nil {
 		writeServerError(w)
 		return
 	}
 
-	user, err := cfg.db.CreateUser(r.Context(), database.CreateUserParams{
-		Email:          request.Email,
-		HashedPassword: hashed_password,
+	var user database.User
+	var verification_token string
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		user, err = q.CreateUser(r.Context(), database.CreateUserParams{
+			Email:          request.Email,
+			HashedPassword: hashed_password,
+			Username:       nullStringFromPtr(request.Username),
+			DisplayName:    stringFromPtr(request.DisplayName),
+			Bio:            stringFromPtr(request.Bio),
+		})
+		if err != nil {
+			return err
+		}
+
+		verification_token, err = issueEmailVerificationToken(r.Context(), q, user.ID, user.Email)
+		return err
 	})
+	if isUniqueViolation(err, usernameUniqueIndex) {
+		writeUsernameTaken(w)
+		return
+	}
 	if err != nil {
 		writeServerError(w)
 		return
 	}
 
-	writeAsJson(
-		w,
-		convertUser(user, "", ""),
-		http.StatusCreated)
+	cfg.sendEmailVerification(user.Email, verification_token)
+
+	cfg.writeUser(w, r, user, "", "", http.StatusCreated)
 }
 
 func (cfg *apiConfig) updateUser(w http.ResponseWriter, r *http.Request) {
@@ -86,26 +151,196 @@ func (cfg *apiConfig) updateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	err = validateProfile(request.Username, request.DisplayName, request.Bio)
+	if err != nil {
+		writeAsJson(
+			w,
+			ErrorResponse{
+				Error: err.Error(),
+			},
+			http.StatusBadRequest)
+		return
+	}
+
 	hashed_password, err := auth.HashPassword(request.Password)
 	if err != nil {
 		writeServerError(w)
 		return
 	}
 
-	user, err := cfg.db.UpdateEmailAndPassword(r.Context(), database.UpdateEmailAndPasswordParams{
-		ID:             userId,
-		Email:          request.Email,
-		HashedPassword: hashed_password,
+	cfg.saveUserUpdate(w, r, userId, userUpdate{
+		HashedPassword: &hashed_password,
+		Email:          &request.Email,
+		Username:       request.Username,
+		DisplayName:    request.DisplayName,
+		Bio:            request.Bio,
 	})
+}
+
+// patchUser updates only the fields present in the request. Changing the
+// email address or password also requires the current password, so a stolen
+// access token cannot be turned into a taken over account.
+func (cfg *apiConfig) patchUser(w http.ResponseWriter, r *http.Request) {
+	userId, err := cfg.validateUserAccess(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	request := PatchUserRequest{}
+
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&request)
 	if err != nil {
 		writeServerError(w)
 		return
 	}
 
-	writeAsJson(
-		w,
-		convertUser(user, "", ""),
-		http.StatusOK)
+	err = validateProfile(request.Username, request.DisplayName, request.Bio)
+	if err != nil {
+		writeAsJson(
+			w,
+			ErrorResponse{
+				Error: err.Error(),
+			},
+			http.StatusBadRequest)
+		return
+	}
+
+	if request.Password != nil && len(*request.Password) == 0 {
+		writeAsJson(
+			w,
+			ErrorResponse{
+				Error: "password must not be empty",
+			},
+			http.StatusBadRequest)
+		return
+	}
+
+	update := userUpdate{
+		Email:       request.Email,
+		Username:    request.Username,
+		DisplayName: request.DisplayName,
+		Bio:         request.Bio,
+	}
+
+	if request.Email != nil || request.Password != nil {
+		if !cfg.checkCurrentPassword(w, r, userId, request.CurrentPassword) {
+			return
+		}
+	}
+
+	if request.Password != nil {
+		hashed_password, err := auth.HashPassword(*request.Password)
+		if err != nil {
+			writeServerError(w)
+			return
+		}
+		update.HashedPassword = &hashed_password
+	}
+
+	cfg.saveUserUpdate(w, r, userId, update)
+}
+
+// checkCurrentPassword writes an error response and returns false unless the
+// current password is given and correct. Wrong guesses count as failed logins
+// and are subject to the same lockout.
+func (cfg *apiConfig) checkCurrentPassword(w http.ResponseWriter, r *http.Request, userId uuid.UUID, currentPassword *string) bool {
+	if currentPassword == nil {
+		writeAsJson(
+			w,
+			ErrorResponse{
+				Error: "current_password is required",
+			},
+			http.StatusBadRequest)
+		return false
+	}
+
+	user, err := cfg.db.GetUserById(r.Context(), userId)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return false
+	}
+
+	if !cfg.allowLoginAttempt(w, r, accountSubject(userId)) {
+		return false
+	}
+
+	isMatch, err := auth.CheckPasswordHash(*currentPassword, user.HashedPassword)
+	if err != nil || !isMatch {
+		err = cfg.recordFailedLogin(r, accountSubject(userId))
+		if err != nil {
+			writeServerError(w)
+			return false
+		}
+
+		writeAsJson(
+			w,
+			ErrorResponse{
+				Error: "current_password is not correct",
+			},
+			http.StatusForbidden)
+		return false
+	}
+
+	return true
+}
+
+// userUpdate holds the changes to a user, where nil fields are left as they
+// are.
+type userUpdate struct {
+	HashedPassword *string
+	Email          *string
+	Username       *string
+	DisplayName    *string
+	Bio            *string
+}
+
+// saveUserUpdate applies an update and writes the updated user. A new email
+// address only replaces the login email once it is verified, so until then
+// the response still shows the current one.
+func (cfg *apiConfig) saveUserUpdate(w http.ResponseWriter, r *http.Request, userId uuid.UUID, update userUpdate) {
+	var user database.User
+	var verification_token string
+	err := cfg.withTx(r.Context(), func(q *database.Queries) error {
+		var err error
+		if update.HashedPassword != nil {
+			err = q.UpdatePassword(r.Context(), database.UpdatePasswordParams{
+				ID:             userId,
+				HashedPassword: *update.HashedPassword,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		user, err = q.UpdateProfile(r.Context(), database.UpdateProfileParams{
+			ID:          userId,
+			Username:    nullStringFromPtr(update.Username),
+			DisplayName: nullStringFromPtr(update.DisplayName),
+			Bio:         nullStringFromPtr(update.Bio),
+		})
+		if err != nil || update.Email == nil || len(*update.Email) == 0 || *update.Email == user.Email {
+			return err
+		}
+
+		verification_token, err = issueEmailVerificationToken(r.Context(), q, userId, *update.Email)
+		return err
+	})
+	if isUniqueViolation(err, usernameUniqueIndex) {
+		writeUsernameTaken(w)
+		return
+	}
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	if len(verification_token) > 0 {
+		cfg.sendEmailVerification(*update.Email, verification_token)
+	}
+
+	cfg.writeUser(w, r, user, "", "", http.StatusOK)
 }
 
 func (cfg *apiConfig) loginUser(w http.ResponseWriter, r *http.Request) {
@@ -118,39 +353,124 @@ func (cfg *apiConfig) loginUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !cfg.allowLoginAttempt(w, r, "") {
+		return
+	}
+
+	// Unknown addresses are throttled the same way as accounts, so the
+	// response does not tell whether the address has an account.
 	user, err := cfg.db.GetUserByEmail(r.Context(), request.Email)
+	if errors.Is(err, sql.ErrNoRows) {
+		account := unknownAccountSubject(request.Email)
+		if cfg.allowLoginAttempt(w, r, account) {
+			cfg.writeFailedLogin(w, r, account)
+		}
+		return
+	}
 	if err != nil {
-		w.WriteHeader(http.StatusUnauthorized)
+		writeServerError(w)
+		return
+	}
+
+	account := accountSubject(user.ID)
+	if !cfg.allowLoginAttempt(w, r, account) {
 		return
 	}
 
 	isMatch, err := auth.CheckPasswordHash(request.Password, user.HashedPassword)
 	if err != nil || !isMatch {
-		w.WriteHeader(http.StatusUnauthorized)
+		cfg.writeFailedLogin(w, r, account)
 		return
 	}
 
-	refresh_token, err := auth.MakeRefreshToken()
+	if user.TotpEnabledAt.Valid {
+		mfa_token, err := cfg.tokenKeys.MakeMFAToken(user.ID, mfaChallengeExpiry)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		writeAsJson(
+			w,
+			MFAChallengeResponse{
+				MfaRequired: true,
+				MfaToken:    mfa_token,
+			},
+			http.StatusOK)
+		return
+	}
+
+	cfg.completeLogin(w, r, user)
+}
+
+// loginUserMFA is the second step of a login for users with two-factor
+// authentication, exchanging the challenge token from loginUser and a TOTP or
+// recovery code for access and refresh tokens.
+func (cfg *apiConfig) loginUserMFA(w http.ResponseWriter, r *http.Request) {
+	request := MFALoginRequest{}
+
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&request)
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	userId, err := cfg.tokenKeys.ValidateMFAToken(request.MfaToken)
 	if err != nil {
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
 
-	_, err = cfg.db.RegisterRefreshToken(
-		r.Context(),
-		database.RegisterRefreshTokenParams{
-			Token:     refresh_token,
-			UserID:    user.ID,
-			ExpiresAt: time.Now().UTC().Add(60 * 24 * time.Hour),
-		})
+	user, err := cfg.db.GetUserById(r.Context(), userId)
+	if err != nil || !user.TotpEnabledAt.Valid {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	if !cfg.allowLoginAttempt(w, r, accountSubject(user.ID)) {
+		return
+	}
+
+	ok, err := verifySecondFactor(r.Context(), cfg.db, user, request.Code)
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+	if !ok {
+		cfg.writeFailedLogin(w, r, accountSubject(user.ID))
+		return
+	}
+
+	cfg.completeLogin(w, r, user)
+}
+
+// completeLogin issues tokens once every factor has been checked. Only then is
+// the account's failure count cleared, so knowing the password alone does not
+// reset the count while guessing second factor codes. Logging in also restores
+// an account that is pending deletion.
+func (cfg *apiConfig) completeLogin(w http.ResponseWriter, r *http.Request, user database.User) {
+	_, err := cfg.clearAccountLockout(r.Context(), user.ID)
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	err = cfg.db.CancelUserDeletion(r.Context(), user.ID)
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	refresh_token, err := issueRefreshToken(r, cfg.db, user.ID, uuid.New())
 	if err != nil {
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
 
-	access_token, err := auth.MakeJWT(
+	access_token, err := cfg.tokenKeys.MakeJWT(
 		user.ID,
-		cfg.tokenSecret,
+		string(user.Role),
 		time.Hour,
 	)
 	if err != nil {
@@ -158,12 +478,13 @@ func (cfg *apiConfig) loginUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	writeAsJson(
-		w,
-		convertUser(user, access_token, refresh_token),
-		http.StatusOK)
+	cfg.writeUser(w, r, user, access_token, refresh_token, http.StatusOK)
 }
 
+// getAccessToken exchanges a refresh token for a new access token and a new
+// refresh token in the same family, revoking the presented one. Presenting a
+// token that was already rotated means it was copied, so the whole family is
+// revoked and the legitimate holder has to log in again.
 func (cfg *apiConfig) getAccessToken(w http.ResponseWriter, r *http.Request) {
 	refresh_token, err := auth.GetBearerToken(r.Header)
 	if err != nil {
@@ -171,15 +492,43 @@ func (cfg *apiConfig) getAccessToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := cfg.db.GetUserByRefreshToken(r.Context(), refresh_token)
-	if err != nil {
+	token_hash := auth.HashToken(refresh_token)
+
+	var user database.User
+	var new_refresh_token string
+	reuseDetected := false
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		token, err := q.GetRefreshTokenForUpdate(r.Context(), token_hash)
+		if err != nil {
+			return err
+		}
+
+		if token.RotatedAt.Valid {
+			reuseDetected = true
+			return q.RevokeRefreshTokenFamily(r.Context(), token.FamilyID)
+		}
+
+		user, err = q.GetUserByRefreshToken(r.Context(), token_hash)
+		if err != nil {
+			return err
+		}
+
+		err = q.RotateRefreshToken(r.Context(), token_hash)
+		if err != nil {
+			return err
+		}
+
+		new_refresh_token, err = issueRefreshToken(r, q, user.ID, token.FamilyID)
+		return err
+	})
+	if err != nil || reuseDetected {
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
 
-	access_token, err := auth.MakeJWT(
+	access_token, err := cfg.tokenKeys.MakeJWT(
 		user.ID,
-		cfg.tokenSecret,
+		string(user.Role),
 		time.Hour,
 	)
 	if err != nil {
@@ -190,12 +539,38 @@ func (cfg *apiConfig) getAccessToken(w http.ResponseWriter, r *http.Request) {
 	writeAsJson(
 		w,
 		AccessTokenResponse{
-			Token: access_token,
+			Token:        access_token,
+			RefreshToken: new_refresh_token,
 		},
 		http.StatusOK,
 	)
 }
 
+// issueRefreshToken registers a new refresh token in the given family,
+// recording the client that asked for it so the session can be listed.
+func issueRefreshToken(r *http.Request, q *database.Queries, userId uuid.UUID, familyId uuid.UUID) (string, error) {
+	refresh_token, err := auth.MakeRefreshToken()
+	if err != nil {
+		return "", err
+	}
+
+	_, err = q.RegisterRefreshToken(
+		r.Context(),
+		database.RegisterRefreshTokenParams{
+			TokenHash: auth.HashToken(refresh_token),
+			UserID:    userId,
+			ExpiresAt: time.Now().UTC().Add(refreshTokenLifetime),
+			FamilyID:  familyId,
+			UserAgent: r.UserAgent(),
+			IpAddress: clientAddress(r),
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return refresh_token, nil
+}
+
 func (cfg *apiConfig) revokeRefreshToken(w http.ResponseWriter, r *http.Request) {
 	refresh_token, err := auth.GetBearerToken(r.Header)
 	if err != nil {
@@ -203,7 +578,7 @@ func (cfg *apiConfig) revokeRefreshToken(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	err = cfg.db.RevokeRefreshToken(r.Context(), refresh_token)
+	err = cfg.db.RevokeRefreshToken(r.Context(), auth.HashToken(refresh_token))
 	if err != nil {
 		w.WriteHeader(http.StatusUnauthorized)
 		return
@@ -212,39 +587,147 @@ func (cfg *apiConfig) revokeRefreshToken(w http.ResponseWriter, r *http.Request)
 	w.WriteHeader(http.StatusNoContent)
 }
 
-func convertUser(user database.User, access_token string, refresh_token string) UserResponse {
+const usernameUniqueIndex = "users_username_lower_idx"
+
+func isUniqueViolation(err error, constraint string) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == constraint
+}
+
+func writeUsernameTaken(w http.ResponseWriter) {
+	writeAsJson(
+		w,
+		ErrorResponse{
+			Error: "username is already taken",
+		},
+		http.StatusConflict)
+}
+
+func nullStringPtr(value sql.NullString) *string {
+	if !value.Valid {
+		return nil
+	}
+	return &value.String
+}
+
+func nullTimePtr(value sql.NullTime) *time.Time {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Time
+}
+
+func nullTimeFromPtr(value *time.Time) sql.NullTime {
+	if value == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *value, Valid: true}
+}
+
+func nullStringFromPtr(value *string) sql.NullString {
+	if value == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: *value, Valid: true}
+}
+
+func stringFromPtr(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+// writeUser responds with the user, looking up whether they currently have an
+// active Chirpy Red subscription.
+func (cfg *apiConfig) writeUser(w http.ResponseWriter, r *http.Request, user database.User, access_token string, refresh_token string, status int) {
+	isChirpyRed, err := cfg.db.IsChirpyRed(r.Context(), user.ID)
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	writeAsJson(
+		w,
+		convertUser(user, isChirpyRed, access_token, refresh_token),
+		status)
+}
+
+func convertUser(user database.User, isChirpyRed bool, access_token string, refresh_token string) UserResponse {
 	return UserResponse{
-		Id:           user.ID,
-		CreatedAt:    user.CreatedAt,
-		UpdatedAt:    user.UpdatedAt,
-		Email:        user.Email,
-		IsChirpyRed:  user.IsChirpyRed,
-		Token:        access_token,
-		RefreshToken: refresh_token,
+		Id:            user.ID,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerifiedAt.Valid,
+		Username:      nullStringPtr(user.Username),
+		DisplayName:   user.DisplayName,
+		Bio:           user.Bio,
+		Role:          string(user.Role),
+		IsChirpyRed:   isChirpyRed,
+		Token:         access_token,
+		RefreshToken:  refresh_token,
 	}
 }
 
 func (cfg *apiConfig) validateUserAccess(r *http.Request) (uuid.UUID, error) {
-	access_token, err := auth.GetBearerToken(r.Header)
+	claims, err := cfg.validateUserClaims(r)
 	if err != nil {
 		return uuid.Nil, err
 	}
 
-	return auth.ValidateJWT(access_token, cfg.tokenSecret)
+	return uuid.Parse(claims.Subject)
 }
 
-func (cfg *apiConfig) upgradeUserRed(w http.ResponseWriter, r *http.Request, eventData UserUpgradedEventData) {
-	_, err := cfg.db.GetUserById(r.Context(), eventData.UserId)
+var errTokenInvalidated = errors.New("access token was invalidated")
+
+// invalidateUserTokens rejects every access token issued to the user so far.
+// The cutoff is taken from the clock that stamps tokens rather than the
+// database's, so the next login is accepted right away.
+func invalidateUserTokens(ctx context.Context, q *database.Queries, userId uuid.UUID) error {
+	return q.InvalidateUserTokens(ctx, database.InvalidateUserTokensParams{
+		ID:                  userId,
+		TokensInvalidBefore: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+	})
+}
+
+// validateUserClaims parses the access token and rejects tokens issued before
+// the user last logged out everywhere.
+func (cfg *apiConfig) validateUserClaims(r *http.Request) (auth.Claims, error) {
+	access_token, err := auth.GetBearerToken(r.Header)
 	if err != nil {
-		w.WriteHeader(http.StatusNotFound)
-		return
+		return auth.Claims{}, err
 	}
 
-	_, err = cfg.db.UpgradeToRed(r.Context(), eventData.UserId)
+	claims, err := cfg.tokenKeys.ParseJWT(access_token)
 	if err != nil {
-		writeServerError(w)
-		return
+		return auth.Claims{}, err
 	}
 
-	w.WriteHeader(http.StatusNoContent)
+	userId, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return auth.Claims{}, err
+	}
+
+	invalidBefore, err := cfg.db.GetUserTokensInvalidBefore(r.Context(), userId)
+	if err != nil {
+		return auth.Claims{}, err
+	}
+
+	if invalidBefore.Valid && (claims.IssuedAt == nil || claims.IssuedAt.Time.Before(invalidBefore.Time)) {
+		return auth.Claims{}, errTokenInvalidated
+	}
+
+	return claims, nil
+}
+
+// viewerAccess identifies the caller from an optional access token. Unlike
+// validateUserAccess, a missing or invalid token yields an anonymous viewer.
+func (cfg *apiConfig) viewerAccess(r *http.Request) uuid.NullUUID {
+	userId, err := cfg.validateUserAccess(r)
+	if err != nil {
+		return uuid.NullUUID{}
+	}
+
+	return uuid.NullUUID{UUID: userId, Valid: true}
 }
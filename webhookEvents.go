@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmaeagle99/chirpy/internal/database"
+)
+
+type WebhookEventResponse struct {
+	Id          uuid.UUID       `json:"id"`
+	EventId     string          `json:"event_id"`
+	EventType   string          `json:"event_type"`
+	Payload     json.RawMessage `json:"payload"`
+	ReceivedAt  time.Time       `json:"received_at"`
+	Status      string          `json:"status"`
+	Attempts    int32           `json:"attempts"`
+	Error       *string         `json:"error"`
+	ProcessedAt *time.Time      `json:"processed_at"`
+}
+
+type WebhookEventsPageResponse struct {
+	Events     []WebhookEventResponse `json:"events"`
+	NextCursor string                 `json:"next_cursor,omitempty"`
+}
+
+// processWebhookEvent handles a claimed event and records the outcome on it.
+// Event types we do not act on are recorded as ignored. It carries on when the
+// caller goes away, so a claimed event is not left unfinished.
+func (cfg *apiConfig) processWebhookEvent(ctx context.Context, event database.WebhookEvent) (database.WebhookEvent, error) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), webhookProcessTimeout)
+	defer cancel()
+
+	request := WebhookEventRequest{}
+
+	err := json.Unmarshal([]byte(event.Payload), &request)
+	status := database.WebhookEventStatusProcessed
+	if err == nil {
+		switch eventData := request.Data.(type) {
+		case UserUpgradedEventData:
+			err = cfg.upgradeUserRed(ctx, eventData)
+		case UserDowngradedEventData:
+			err = cfg.downgradeUserRed(ctx, eventData)
+		case SubscriptionRenewedEventData:
+			err = cfg.renewSubscription(ctx, eventData)
+		case SubscriptionCanceledEventData:
+			err = cfg.cancelSubscription(ctx, eventData)
+		case PaymentFailedEventData:
+			err = cfg.failSubscriptionPayment(ctx, eventData)
+		default:
+			status = database.WebhookEventStatusIgnored
+		}
+	}
+
+	result := sql.NullString{}
+	if err != nil {
+		status = database.WebhookEventStatusFailed
+		result = sql.NullString{String: err.Error(), Valid: true}
+	}
+
+	finished, finishErr := cfg.db.FinishWebhookEvent(ctx, database.FinishWebhookEventParams{
+		ID:     event.ID,
+		Status: status,
+		Error:  result,
+	})
+	if finishErr != nil {
+		return event, finishErr
+	}
+
+	return finished, err
+}
+
+func (cfg *apiConfig) getWebhookEvents(w http.ResponseWriter, r *http.Request) {
+	status := database.NullWebhookEventStatus{}
+	status_qparam := r.URL.Query().Get("status")
+	if len(status_qparam) > 0 {
+		status = database.NullWebhookEventStatus{
+			WebhookEventStatus: database.WebhookEventStatus(status_qparam),
+			Valid:              true,
+		}
+
+		switch status.WebhookEventStatus {
+		case database.WebhookEventStatusReceived,
+			database.WebhookEventStatusProcessed,
+			database.WebhookEventStatusIgnored,
+			database.WebhookEventStatusFailed:
+		default:
+			writeAsJson(
+				w,
+				ErrorResponse{
+					Error: "status is not valid",
+				},
+				http.StatusBadRequest)
+			return
+		}
+	}
+
+	page, err := parsePageRequest(r, sortDesc)
+	if err != nil {
+		writeAsJson(
+			w,
+			ErrorResponse{
+				Error: err.Error(),
+			},
+			http.StatusBadRequest)
+		return
+	}
+
+	events, err := cfg.db.ListWebhookEvents(r.Context(), database.ListWebhookEventsParams{
+		Status:           status,
+		BeforeReceivedAt: page.cursorCreatedAt(),
+		BeforeID:         page.cursorId(),
+		Limit:            page.queryLimit(),
+	})
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	events, next_cursor := trimPage(page, events, func(event database.WebhookEvent) pageCursor {
+		return pageCursor{
+			CreatedAt: event.ReceivedAt,
+			Id:        event.ID,
+		}
+	})
+
+	writeAsJson(
+		w,
+		WebhookEventsPageResponse{
+			Events:     Map(events, convertWebhookEvent),
+			NextCursor: next_cursor,
+		},
+		http.StatusOK)
+}
+
+// replayWebhookEvent processes a failed event again from its stored payload,
+// for example once the cause of the failure has been fixed. Events whose
+// processing was abandoned can be replayed too.
+func (cfg *apiConfig) replayWebhookEvent(w http.ResponseWriter, r *http.Request) {
+	eventId, err := uuid.Parse(r.PathValue("eventID"))
+	if err != nil {
+		writeAsJson(
+			w,
+			ErrorResponse{
+				Error: "eventId is not valid",
+			},
+			http.StatusBadRequest)
+		return
+	}
+
+	event, err := cfg.db.ReclaimWebhookEvent(r.Context(), database.ReclaimWebhookEventParams{
+		ID:          eventId,
+		StaleBefore: time.Now().UTC().Add(-webhookClaimTimeout),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		_, err = cfg.db.GetWebhookEvent(r.Context(), eventId)
+		if errors.Is(err, sql.ErrNoRows) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			writeServerError(w)
+			return
+		}
+
+		writeAsJson(
+			w,
+			ErrorResponse{
+				Error: "only failed or abandoned events can be replayed",
+			},
+			http.StatusConflict)
+		return
+	}
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	// A failure while processing is recorded on the event, which is returned
+	// either way.
+	event, err = cfg.processWebhookEvent(r.Context(), event)
+	if err != nil && event.Status != database.WebhookEventStatusFailed {
+		writeServerError(w)
+		return
+	}
+
+	writeAsJson(
+		w,
+		convertWebhookEvent(event),
+		http.StatusOK)
+}
+
+func convertWebhookEvent(event database.WebhookEvent) WebhookEventResponse {
+	payload := json.RawMessage(event.Payload)
+	if !json.Valid(payload) {
+		payload, _ = json.Marshal(event.Payload)
+	}
+
+	return WebhookEventResponse{
+		Id:          event.ID,
+		EventId:     event.EventID,
+		EventType:   event.EventType,
+		Payload:     payload,
+		ReceivedAt:  event.ReceivedAt,
+		Status:      string(event.Status),
+		Attempts:    event.Attempts,
+		Error:       nullStringPtr(event.Error),
+		ProcessedAt: nullTimePtr(event.ProcessedAt),
+	}
+}
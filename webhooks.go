@@ -1,26 +1,56 @@
 package main
 
 import (
+	"crypto/subtle"
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
+	"os"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmaeagle99/chirpy/internal/auth"
+	"github.com/jmaeagle99/chirpy/internal/database"
+	"github.com/jmaeagle99/chirpy/internal/subscription"
 )
 
 type WebhookEventData interface{}
 
 type WebhookEventRequest struct {
+	Id        string
 	EventType string
 	Data      WebhookEventData
 }
 
 type UserUpgradedEventData struct {
+	UserId           uuid.UUID  `json:"user_id"`
+	Plan             string     `json:"plan"`
+	CurrentPeriodEnd *time.Time `json:"current_period_end"`
+}
+
+type UserDowngradedEventData struct {
+	UserId uuid.UUID `json:"user_id"`
+}
+
+type SubscriptionRenewedEventData struct {
+	UserId           uuid.UUID  `json:"user_id"`
+	CurrentPeriodEnd *time.Time `json:"current_period_end"`
+}
+
+type SubscriptionCanceledEventData struct {
+	UserId           uuid.UUID  `json:"user_id"`
+	CurrentPeriodEnd *time.Time `json:"current_period_end"`
+}
+
+type PaymentFailedEventData struct {
 	UserId uuid.UUID `json:"user_id"`
 }
 
 func (request *WebhookEventRequest) UnmarshalJSON(data []byte) error {
 	var eventMetadata struct {
+		Id        string          `json:"id"`
 		EventType string          `json:"event"`
 		Data      json.RawMessage `json:"data"`
 	}
@@ -29,44 +59,133 @@ func (request *WebhookEventRequest) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
+	request.Id = eventMetadata.Id
+	request.EventType = eventMetadata.EventType
+
+	var err error
 	switch eventMetadata.EventType {
 	case "user.upgraded":
-		eventData := UserUpgradedEventData{}
-		if err := json.Unmarshal(eventMetadata.Data, &eventData); err != nil {
-			return err
-		}
-		request.Data = eventData
+		request.Data, err = unmarshalEventData[UserUpgradedEventData](eventMetadata.Data)
+	case "user.downgraded":
+		request.Data, err = unmarshalEventData[UserDowngradedEventData](eventMetadata.Data)
+	case "subscription.renewed":
+		request.Data, err = unmarshalEventData[SubscriptionRenewedEventData](eventMetadata.Data)
+	case "subscription.canceled":
+		request.Data, err = unmarshalEventData[SubscriptionCanceledEventData](eventMetadata.Data)
+	case "payment.failed":
+		request.Data, err = unmarshalEventData[PaymentFailedEventData](eventMetadata.Data)
 	}
+	return err
+}
 
-	request.EventType = eventMetadata.EventType
-	return nil
+func unmarshalEventData[T any](data json.RawMessage) (WebhookEventData, error) {
+	var eventData T
+	if err := json.Unmarshal(data, &eventData); err != nil {
+		return nil, err
+	}
+	return eventData, nil
 }
 
+const (
+	webhookTimestampTolerance = 5 * time.Minute
+	maxWebhookBodyBytes       = 1 << 20
+	webhookProcessTimeout     = 30 * time.Second
+	// webhookClaimTimeout is how long an event may stay received before it
+	// counts as abandoned and can be claimed again. It has to be longer than
+	// webhookProcessTimeout.
+	webhookClaimTimeout = 5 * time.Minute
+)
+
 func (cfg *apiConfig) handleWebhook(w http.ResponseWriter, r *http.Request) {
-	api_key, err := auth.GetAPIKey(r.Header)
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBodyBytes))
 	if err != nil {
-		w.WriteHeader(http.StatusUnauthorized)
+		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
 
-	if api_key != cfg.polkaKey {
+	err = cfg.authenticateWebhook(r.Header, body)
+	if err != nil {
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
 
+	// A body that does not parse is still logged, and recorded as failed when
+	// it is processed.
 	request := WebhookEventRequest{}
+	parseErr := json.Unmarshal(body, &request)
 
-	decoder := json.NewDecoder(r.Body)
-	err = decoder.Decode(&request)
+	event, err := cfg.db.ClaimWebhookEvent(r.Context(), database.ClaimWebhookEventParams{
+		EventID:     webhookEventId(request),
+		EventType:   request.EventType,
+		Payload:     string(body),
+		StaleBefore: time.Now().UTC().Add(-webhookClaimTimeout),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		// Already handled, or being handled by another delivery right now.
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
 	if err != nil {
 		writeServerError(w)
 		return
 	}
 
-	switch eventData := request.Data.(type) {
-	case UserUpgradedEventData:
-		cfg.upgradeUserRed(w, r, eventData)
-	default:
-		w.WriteHeader(http.StatusNoContent)
+	_, err = cfg.processWebhookEvent(r.Context(), event)
+	if parseErr != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, errWebhookUserNotFound) || errors.Is(err, subscription.ErrNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		writeServerError(w)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// webhookEventId identifies an event for deduplication. Only events that
+// carry an id can be recognized as retries; the same body can legitimately
+// arrive twice, such as an upgrade after a downgrade, so events without an id
+// are always processed and logged under a generated one.
+func webhookEventId(request WebhookEventRequest) string {
+	if len(request.Id) > 0 {
+		return request.Id
+	}
+	return "generated:" + uuid.NewString()
+}
+
+// authenticateWebhook requires a valid signature once webhook secrets are
+// configured. Until then it falls back to comparing the static API key.
+func (cfg *apiConfig) authenticateWebhook(headers http.Header, body []byte) error {
+	if len(cfg.webhookSecrets) > 0 {
+		return auth.VerifyWebhook(headers, body, cfg.webhookSecrets, time.Now(), webhookTimestampTolerance)
+	}
+
+	api_key, err := auth.GetAPIKey(headers)
+	if err != nil {
+		return err
+	}
+
+	if len(cfg.polkaKey) == 0 || subtle.ConstantTimeCompare([]byte(api_key), []byte(cfg.polkaKey)) != 1 {
+		return errors.New("api key does not match")
+	}
+
+	return nil
+}
+
+// loadWebhookSecrets reads POLKA_WEBHOOK_SECRET and, while rotating,
+// POLKA_WEBHOOK_SECRET_PREVIOUS. Signatures made with either are accepted.
+func loadWebhookSecrets() []string {
+	secrets := []string{}
+	for _, name := range []string{"POLKA_WEBHOOK_SECRET", "POLKA_WEBHOOK_SECRET_PREVIOUS"} {
+		secret := os.Getenv(name)
+		if len(secret) > 0 {
+			secrets = append(secrets, secret)
+		}
 	}
+	return secrets
 }